@@ -0,0 +1,198 @@
+package display
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/plugin"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// builtins holds the built-in commands, inspector rows and panels. Entries in
+// plugin.Default take precedence over them.
+var builtins = plugin.NewRegistry()
+
+// commandHandler is the signature of the built-in command handlers.
+type commandHandler func(m Model, command string, args []string) (Model, tea.Cmd)
+
+func init() {
+	commands := []plugin.Command{
+		{Name: "q", Aliases: []string{"quit", "q!", "quit!"}, Usage: "q[!]", Run: builtin(handleQuitCommand)},
+		{Name: "w", Aliases: []string{"write", "wq", "w!", "write!", "wq!"}, Usage: "w[!] [range] [file]", Run: builtin(handleWriteCommand)},
+		{Name: "r", Aliases: []string{"read"}, Usage: "r <file>", Run: builtin(handleReadCommand)},
+		{Name: "goto", Usage: "goto <address>", Run: builtin(handleGotoCommand)},
+		{Name: "set", Usage: "set <option>[=<value>]...", Run: builtin(handleSetCommand)},
+		{Name: "ftset", Usage: "ftset <filetype> <option>[=<value>]...", Run: builtin(handleFtsetCommand)},
+		{Name: "colorscheme", Aliases: []string{"colo"}, Usage: "colorscheme [name]", Run: builtin(handleColorschemeCommand)},
+		{Name: "%!", Usage: "%!", Run: builtin(func(m Model, command string, args []string) (Model, tea.Cmd) {
+			return editDump(m)
+		})},
+		{Name: "inspector", Usage: "inspector [add|remove|list] ...", Run: builtin(func(m Model, command string, args []string) (Model, tea.Cmd) {
+			return handleInspectorCommand(m, args)
+		})},
+		{Name: "lua", Usage: "lua <code>", Run: builtin(func(m Model, command string, args []string) (Model, tea.Cmd) {
+			return handleLuaCommand(m, args)
+		})},
+		{Name: "run", Usage: "run <file>", Run: builtin(handleSourceCommand)},
+		{Name: "source", Aliases: []string{"so"}, Usage: "source <file>", Run: builtin(handleSourceCommand)},
+		{Name: "fill", Usage: "fill <pattern>", Run: builtin(handleFillCommand)},
+		{Name: "insert", Usage: "insert <count> [pattern]", Run: builtin(handleInsertCommand)},
+		{Name: "truncate", Usage: "truncate [address]", Run: builtin(handleTruncateCommand)},
+		{Name: "resize", Usage: "resize <size> [byte]", Run: builtin(handleResizeCommand)},
+		{Name: "xor", Usage: "xor <key>", Run: builtin(handleKeyTransformCommand)},
+		{Name: "and", Usage: "and <key>", Run: builtin(handleKeyTransformCommand)},
+		{Name: "or", Usage: "or <key>", Run: builtin(handleKeyTransformCommand)},
+		{Name: "not", Usage: "not", Run: builtin(func(m Model, command string, args []string) (Model, tea.Cmd) {
+			return m.transformSelection(func(data []byte) ([]byte, error) { return util.NOT(data), nil })
+		})},
+		{Name: "add", Usage: "add <value> [size] [le|be]", Run: builtin(handleArithCommand)},
+		{Name: "sub", Usage: "sub <value> [size] [le|be]", Run: builtin(handleArithCommand)},
+		{Name: "rol", Usage: "rol <bits> [size] [le|be]", Run: builtin(handleBitShiftCommand)},
+		{Name: "ror", Usage: "ror <bits> [size] [le|be]", Run: builtin(handleBitShiftCommand)},
+		{Name: "shl", Usage: "shl <bits> [size] [le|be]", Run: builtin(handleBitShiftCommand)},
+		{Name: "shr", Usage: "shr <bits> [size] [le|be]", Run: builtin(handleBitShiftCommand)},
+		{Name: "bswap", Usage: "bswap [size]", Run: builtin(handleByteSwapCommand)},
+		{Name: "reverse", Usage: "reverse", Run: builtin(func(m Model, command string, args []string) (Model, tea.Cmd) {
+			return m.transformSelection(func(data []byte) ([]byte, error) { return util.Reverse(data), nil })
+		})},
+		{Name: "hash", Usage: "hash [algorithm] [address [le|be]]", Run: builtin(handleHashCommand)},
+		{Name: "checksum", Usage: "checksum [list|add <rule>|remove <number>|fix|save]", Run: builtin(handleChecksumCommand)},
+		{Name: "encode", Usage: "encode <codec>", Run: builtin(handleCodecCommand)},
+		{Name: "decode", Usage: "decode <codec>", Run: builtin(handleCodecCommand)},
+		{Name: "unpack", Usage: "unpack <codec>", Run: builtin(handleUnpackCommand)},
+		{Name: "export", Usage: "export [format] [range] <file>", Run: builtin(handleExportCommand)},
+		{Name: "import", Usage: "import [format] <file>", Run: builtin(handleImportCommand)},
+		{Name: "rpc", Usage: "rpc [listen [path]|stop]", Run: builtin(handleRPCCommand)},
+		{
+			Name:    "map",
+			Aliases: []string{"nmap", "vmap", "noremap", "nnoremap", "vnoremap", "unmap", "nunmap", "vunmap"},
+			Usage:   "map [<lhs> [<rhs>]]",
+			Run:     builtin(handleMapCommand),
+		},
+	}
+	for _, cmd := range commands {
+		if err := builtins.RegisterCommand(cmd); err != nil {
+			panic(err)
+		}
+	}
+
+	builtins.RegisterInspector("standard", util.Inspect)
+
+	if err := builtins.RegisterPanel(plugin.Panel{
+		Name:   "Inspector",
+		Status: inspectorStatus,
+		Render: renderInspector,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// builtin adapts a built-in command handler to a plugin command.
+func builtin(handler commandHandler) plugin.CommandFunc {
+	return func(ed plugin.Editor, args []string) error {
+		e, ok := ed.(*pluginEditor)
+		if !ok {
+			return fmt.Errorf("%s can only be run by the editor", args[0])
+		}
+
+		var cmd tea.Cmd
+		*e.m, cmd = handler(*e.m, args[0], args[1:])
+		e.cmds = append(e.cmds, cmd)
+		return nil
+	}
+}
+
+// lookupCommand returns the command with the given name or alias, or nil if
+// there is no such command.
+func lookupCommand(name string) *plugin.Command {
+	if cmd := plugin.Default.Command(name); cmd != nil {
+		return cmd
+	}
+	return builtins.Command(name)
+}
+
+// panels returns the panels to show, with the built-in panels first.
+func panels() []plugin.Panel {
+	all := make([]plugin.Panel, 0)
+	for _, p := range builtins.Panels() {
+		if override := plugin.Default.Panel(p.Name); override != nil {
+			p = *override
+		}
+		all = append(all, p)
+	}
+	for _, p := range plugin.Default.Panels() {
+		if builtins.Panel(p.Name) == nil {
+			all = append(all, p)
+		}
+	}
+	return all
+}
+
+// pluginEditor gives plugins access to a model. Status messages and commands
+// produced while a plugin runs are collected and returned by result.
+type pluginEditor struct {
+	m    *Model
+	cmds []tea.Cmd
+}
+
+var _ plugin.Editor = &pluginEditor{}
+
+func (e *pluginEditor) Buffer() *core.EditorBuffer {
+	return e.m.eb
+}
+
+func (e *pluginEditor) SetCursor(pos int64) {
+	e.m.SetCursor(pos)
+}
+
+func (e *pluginEditor) Option(name string) (string, error) {
+	opt := LookupOption(name)
+	if opt == nil {
+		return "", fmt.Errorf("unknown option: %s", name)
+	}
+	return opt.Get(e.m), nil
+}
+
+func (e *pluginEditor) SetOption(name, value string) error {
+	return e.m.SetOption(name, value)
+}
+
+func (e *pluginEditor) ExecCommand(line string) error {
+	err := e.m.ExecCommand(line)
+	if e.m.quitting {
+		e.cmds = append(e.cmds, tea.Quit)
+	}
+	return err
+}
+
+func (e *pluginEditor) Prompt(label string, callback func(ed plugin.Editor, input string) error) {
+	e.m.Prompt(label, func(m Model, input string) (Model, tea.Cmd) {
+		ed := &pluginEditor{m: &m}
+		err := callback(ed, input)
+		return m, ed.result(err)
+	})
+}
+
+func (e *pluginEditor) Print(text string) {
+	e.cmds = append(e.cmds, TeaMsgCmd(StatusTextMsg{Text: text}))
+}
+
+// result returns the commands produced by the plugin, followed by the error
+// if there is one.
+func (e *pluginEditor) result(err error) tea.Cmd {
+	if err != nil {
+		e.cmds = append(e.cmds, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true}))
+	}
+
+	cmds := make([]tea.Cmd, 0, len(e.cmds))
+	for _, cmd := range e.cmds {
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	if len(cmds) == 1 {
+		return cmds[0]
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,148 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+)
+
+// checksumCache holds the number of failing checksum rules, so that the
+// checksums are only computed again after the buffer or the rules change.
+type checksumCache struct {
+	eb       *core.EditorBuffer
+	revision int
+	bad      int
+}
+
+// loadChecksumRules loads the checksum rules of the buffer's file.
+func (m *Model) loadChecksumRules() error {
+	rules, err := core.LoadChecksumRules(m.eb.Name)
+	m.setChecksumRules(rules)
+	return err
+}
+
+// setChecksumRules replaces the checksum rules.
+func (m *Model) setChecksumRules(rules []core.ChecksumRule) {
+	m.checksums = rules
+	m.checksumCache.eb = nil
+}
+
+// checkChecksum returns "ok" or "bad" depending on whether the stored checksum
+// of the rule is correct, or the error if it cannot be computed.
+func (m Model) checkChecksum(r core.ChecksumRule) string {
+	chg, err := m.eb.ChecksumChange(r)
+	switch {
+	case err != nil:
+		return err.Error()
+	case chg != nil:
+		return "bad"
+	}
+	return "ok"
+}
+
+// badChecksums returns the number of checksum rules whose stored checksum is
+// wrong or cannot be computed.
+func (m Model) badChecksums() int {
+	c := m.checksumCache
+	if c.eb == m.eb && c.revision == m.eb.Revision() {
+		return c.bad
+	}
+
+	c.eb, c.revision, c.bad = m.eb, m.eb.Revision(), 0
+	for _, r := range m.checksums {
+		if m.checkChecksum(r) != "ok" {
+			c.bad++
+		}
+	}
+	return c.bad
+}
+
+// fixChecksums stores the correct checksum for every rule, in order, as a
+// single undo step. Nothing is changed if any rule does not fit the buffer.
+// It returns the number of checksums that were updated.
+func (m *Model) fixChecksums() (int, error) {
+	for _, r := range m.checksums {
+		if _, _, err := m.eb.Checksum(r); err != nil {
+			return 0, fmt.Errorf("%s: %w", r, err)
+		}
+	}
+
+	since := len(m.eb.UndoStack)
+	for _, r := range m.checksums {
+		chg, err := m.eb.ChecksumChange(r)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", r, err)
+		}
+		if chg != nil {
+			m.eb.PreviewChange(chg)
+			m.eb.CommitChange()
+		}
+	}
+	m.eb.GroupChanges(since)
+	return len(m.eb.UndoStack) - since, nil
+}
+
+// handleChecksumCommand lists, adds, removes, fixes and saves the checksum
+// rules.
+func handleChecksumCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "list":
+		if len(m.checksums) == 0 {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "No checksum rules"})
+		}
+		lines := make([]string, len(m.checksums))
+		for i, r := range m.checksums {
+			lines[i] = fmt.Sprintf("%d: %s (%s)", i+1, r, m.checkChecksum(r))
+		}
+		return m, TeaMsgCmd(StatusTextMsg{Text: strings.Join(lines, "; ")})
+
+	case "add":
+		r, err := core.ParseChecksumRule(strings.Join(args, " "))
+		if err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+		m.setChecksumRules(append(m.checksums[:len(m.checksums):len(m.checksums)], r))
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Added rule %d: %s", len(m.checksums), r)})
+
+	case "remove":
+		if len(args) != 1 {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: checksum remove <number>"})
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(m.checksums) {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "No such rule: " + args[0], Error: true})
+		}
+		rules := append([]core.ChecksumRule{}, m.checksums[:n-1]...)
+		m.setChecksumRules(append(rules, m.checksums[n:]...))
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Removed rule %d", n)})
+
+	case "fix":
+		n, err := m.fixChecksums()
+		if err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Updated %d of %d checksums", n, len(m.checksums))})
+
+	case "save":
+		var sb strings.Builder
+		for _, r := range m.checksums {
+			sb.WriteString(r.String() + "\n")
+		}
+		path := core.ChecksumRulesPath(m.eb.Name)
+		if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Saved %d rules to %s", len(m.checksums), path)})
+	}
+
+	return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: checksum [list|add <rule>|remove <number>|fix|save]"})
+}
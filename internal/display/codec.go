@@ -0,0 +1,145 @@
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// parentBuffer is a buffer that the current buffer was unpacked from. Writing
+// the current buffer packs it back into its range of the parent.
+type parentBuffer struct {
+	eb        *core.EditorBuffer
+	checksums []core.ChecksumRule
+	viewRow   int
+
+	// Range of the parent holding the packed data. Bytes of the selection
+	// after the end of the packed data, such as padding, are not part of it
+	// and are left alone.
+	offset, length int64
+	codec          *util.Codec
+}
+
+// handleCodecCommand implements encode and decode, which replace the
+// selection with its encoded or decoded form.
+func handleCodecCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) != 1 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: " + command + " <codec>"})
+	}
+	if args[0] == "list" {
+		return m, TeaMsgCmd(StatusTextMsg{Text: strings.Join(util.CodecNames(), " ")})
+	}
+	codec, err := util.LookupCodec(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	fn := codec.Decode
+	if command == "encode" {
+		fn = codec.Encode
+	}
+	return m.transformSelection(func(data []byte) ([]byte, error) {
+		out, err := fn(data)
+		if err != nil {
+			return nil, fmt.Errorf("cannot %s %s: %w", command, codec.Name, err)
+		}
+		return out, nil
+	})
+}
+
+// handleUnpackCommand decodes the selection into a new buffer. Writing the
+// new buffer encodes it again and replaces the packed data in the parent, and
+// quitting it returns to the parent.
+func handleUnpackCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) != 1 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: unpack <codec>"})
+	}
+	codec, err := util.LookupCodec(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if m.eb.Size() == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Buffer is empty", Error: true})
+	}
+
+	start, end := m.eb.GetSelectionRange()
+	data, err := m.readBytes(start, end-start+1)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	decoded, n, err := codec.DecodeStream(data)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("cannot decode %s: %s", codec.Name, err), Error: true})
+	}
+
+	m.parents = append(m.parents[:len(m.parents):len(m.parents)], parentBuffer{
+		eb:        m.eb,
+		checksums: m.checksums,
+		viewRow:   m.viewRow,
+		offset:    start,
+		length:    int64(n),
+		codec:     codec,
+	})
+	m.eb = core.NewEditorBuffer(fmt.Sprintf("%s@0x%x:%s", m.eb.Name, start, codec.Name), bytes.NewReader(decoded))
+	m.setChecksumRules(nil)
+	m.viewRow = 0
+	text := fmt.Sprintf("Unpacked %d bytes into %d bytes", n, len(decoded))
+	if n < len(data) {
+		text += fmt.Sprintf(", followed by %d bytes that are left alone", len(data)-n)
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: text})
+}
+
+// packIntoParent encodes the buffer and replaces its range of the parent
+// with it as a single change. The buffer is then treated as saved. Data that
+// encodes shorter than the range is padded to keep the rest of the parent in
+// place. Data that encodes longer, or that cannot be padded, moves the rest
+// of the parent, so it is only written if grow is set.
+func (m *Model) packIntoParent(grow bool) (string, error) {
+	p := &m.parents[len(m.parents)-1]
+	data, err := m.readBytes(0, m.eb.Size())
+	if err != nil {
+		return "", err
+	}
+	encoded, err := p.codec.Encode(data)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode %s: %w", p.codec.Name, err)
+	}
+
+	size := int64(len(encoded))
+	if size < p.length && p.codec.Pad != nil {
+		encoded = append(encoded, p.codec.Pad(int(p.length-size))...)
+	}
+	if int64(len(encoded)) != p.length && !grow {
+		return "", fmt.Errorf("packed data is %d bytes instead of %d, which moves the rest of the parent (add ! to override)", size, p.length)
+	}
+
+	p.eb.PreviewChange(&core.Change{Position: p.offset, Removed: p.length, Data: encoded})
+	p.eb.CommitChange()
+	p.length = int64(len(encoded))
+
+	// Start over from the packed contents, like a file after saving
+	eb := core.NewEditorBuffer(m.eb.Name, bytes.NewReader(data))
+	eb.Cursor, eb.SelectionStart, eb.Regions = m.eb.Cursor, m.eb.SelectionStart, m.eb.Regions
+	m.eb = eb
+
+	text := fmt.Sprintf("Packed %d bytes into %d bytes at 0x%x of the parent", len(data), size, p.offset)
+	if pad := int64(len(encoded)) - size; pad > 0 {
+		text += fmt.Sprintf(", padded with %d bytes", pad)
+	}
+	return text, nil
+}
+
+// closeChild returns to the parent of the buffer, discarding the buffer.
+func (m *Model) closeChild() {
+	p := m.parents[len(m.parents)-1]
+	m.parents = m.parents[:len(m.parents)-1]
+	m.eb = p.eb
+	m.setChecksumRules(p.checksums)
+	m.viewRow = p.viewRow
+	m.ScrollToCursor()
+}
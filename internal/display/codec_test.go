@@ -0,0 +1,67 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnpackWriteBack(t *testing.T) {
+	assert := assert.New(t)
+
+	zlib, _ := util.LookupCodec("zlib")
+	text := bytes.Repeat([]byte("hello, gex! "), 32)
+	blob, err := zlib.Encode(text)
+	if !assert.NoError(err) {
+		return
+	}
+
+	// A header, the compressed blob padded with 0xff like a flash image, and
+	// a trailer that must stay in place
+	padding := bytes.Repeat([]byte{0xff}, 16)
+	image := string(append(append(append([]byte("HDR:"), blob...), padding...), "TAIL"...))
+	unpack := func() Model {
+		m := newTestModel(image)
+		m.eb.SelectionStart = 4
+		m.eb.Cursor = int64(4 + len(blob) + len(padding) - 1)
+		assert.NoError(m.ExecCommand("unpack zlib"))
+		assert.Equal(string(text), testContents(m))
+		return m
+	}
+
+	// Data that encodes shorter is padded with zeros
+	m := unpack()
+	assert.NoError(m.commitChange(core.Change{Position: 0, Removed: int64(len(text)) - 4, Data: []byte("bye!")}))
+	assert.NoError(m.ExecCommand("w"))
+	assert.NoError(m.ExecCommand("q"))
+	parent := testContents(m)
+	assert.Equal(len(image), len(parent))
+	assert.Equal("HDR:", parent[:4])
+	assert.Equal(string(padding)+"TAIL", parent[4+len(blob):])
+	out, n, err := zlib.DecodeStream([]byte(parent[4 : 4+len(blob)]))
+	assert.NoError(err)
+	assert.Equal("bye!ex! ", string(out))
+	assert.Equal(bytes.Repeat([]byte{0}, len(blob)-n), []byte(parent[4+n:4+len(blob)]))
+
+	// Data that encodes longer needs a '!', and moves the rest of the parent
+	m = unpack()
+	noise := make([]byte, 256)
+	for i := range noise {
+		noise[i] = byte(i * 167)
+	}
+	assert.NoError(m.commitChange(core.Change{Position: 0, Data: noise}))
+	assert.Error(m.ExecCommand("w"))
+	assert.True(m.eb.IsDirty())
+	assert.NoError(m.ExecCommand("w!"))
+	assert.NoError(m.ExecCommand("q"))
+	parent = testContents(m)
+	assert.True(len(parent) > len(image))
+	assert.Equal("HDR:", parent[:4])
+	assert.Equal(string(padding)+"TAIL", parent[len(parent)-len(padding)-4:])
+	out, err = zlib.Decode([]byte(parent[4:]))
+	assert.NoError(err)
+	assert.Equal(string(noise)+string(text), string(out))
+}
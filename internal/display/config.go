@@ -0,0 +1,154 @@
+package display
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultConfigPath returns the path of the user's configuration file, which
+// is gex/gexrc inside the user's configuration directory.
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gex", "gexrc"), nil
+}
+
+// LoadConfig executes the commands in the given configuration file. Empty
+// lines and lines starting with " or # are ignored. Execution continues past
+// failing commands, and all errors are returned together.
+func (m *Model) LoadConfig(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var errs []error
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if isCommentLine(line) {
+			continue
+		}
+
+		if err := m.ExecCommand(line); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", path, lineNo, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ExecScript executes the commands in a script, one per line, skipping the
+// same lines as LoadConfig. Unlike LoadConfig, execution stops at the first
+// failing command or when a command quits the editor. The name is used in
+// error messages.
+func (m *Model) ExecScript(r io.Reader, name string) error {
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan() && !m.quitting; lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if isCommentLine(line) {
+			continue
+		}
+
+		if err := m.ExecCommand(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// isCommentLine returns true if a trimmed line of a script should be skipped.
+func isCommentLine(line string) bool {
+	return line == "" || strings.HasPrefix(line, "\"") || strings.HasPrefix(line, "#")
+}
+
+// splitCommand splits a command line into the command name and its
+// arguments.
+func splitCommand(line string) (string, []string) {
+	split := strings.Split(line, " ")
+	command := split[0]
+	args := []string{}
+	if len(split) > 1 {
+		args = split[1:]
+	}
+	return command, args
+}
+
+// ExecCommand executes a command synchronously, outside of the bubbletea
+// event loop. Messages produced by the command are fed back into the model,
+// and the first error reported by the command is returned.
+func (m *Model) ExecCommand(line string) error {
+	line = strings.TrimPrefix(strings.TrimSpace(line), ":")
+	if line == "" {
+		return nil
+	}
+
+	var cmd tea.Cmd
+	command, args := splitCommand(line)
+	*m, cmd = handleCommand(*m, command, args)
+	return m.runCmdSync(cmd)
+}
+
+// quitIfAsked returns the command followed by a quit if a command executed
+// with ExecCommand, such as one run by a script, has asked the editor to
+// quit. The editor only quits on its own when such commands run headless.
+func (m Model) quitIfAsked(cmd tea.Cmd) tea.Cmd {
+	if !m.quitting {
+		return cmd
+	}
+	if cmd == nil {
+		return tea.Quit
+	}
+	return tea.Batch(cmd, tea.Quit)
+}
+
+// runCmdSync runs the command and all commands resulting from it to
+// completion, feeding the produced messages into the model.
+func (m *Model) runCmdSync(cmd tea.Cmd) error {
+	if cmd == nil {
+		return nil
+	}
+
+	switch msg := cmd().(type) {
+	case nil:
+		return nil
+
+	case tea.QuitMsg:
+		m.quitting = true
+		return nil
+
+	case tea.BatchMsg:
+		var firstErr error
+		for _, c := range msg {
+			if err := m.runCmdSync(c); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+
+	case StatusTextMsg:
+		if msg.Error {
+			return errors.New(msg.Text)
+		}
+		m.StatusMessage(msg.Text, false)
+		return nil
+
+	default:
+		next, cmd := m.Update(msg)
+		*m = next.(Model)
+		return m.runCmdSync(cmd)
+	}
+}
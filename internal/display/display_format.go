@@ -6,16 +6,27 @@ import (
 	"io"
 	"path"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/plugin"
 	"github.com/hizkifw/gex/pkg/util"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/exp/slices"
 )
 
 // RenderHexView renders the hex dump.
 func (m Model) RenderHexView() (string, error) {
-	// Get the list of regions
+	// Get the list of regions, including the ones added by plugins
 	regions := m.eb.GetRegions()
+	viewStart, viewEnd := m.GetViewBounds()
+	if extra := plugin.Default.Regions(&pluginEditor{m: &m}, viewStart, viewEnd); len(extra) > 0 {
+		regions = append(regions, extra...)
+		slices.SortFunc(regions, func(i, j core.Region) int {
+			return int(i.Start - j.Start)
+		})
+	}
 
 	r := m.eb.ReadSeeker()
 	offset := int64(m.viewRow * m.ncols)
@@ -24,50 +35,71 @@ func (m Model) RenderHexView() (string, error) {
 		return "", err
 	}
 
-	// Read view from the underlying buffer, plus some extra bytes to make sure
-	// the inspector can read ahead
-	buf := make([]byte, (m.nrows*m.ncols)+8)
+	// Read view from the underlying buffer
+	buf := make([]byte, m.nrows*m.ncols)
 	n, err := r.Read(buf)
 	if err != nil && err != io.EOF {
 		return "", err
 	}
 
+	// Ranges without data, such as the gaps in an Intel HEX file
+	holes := m.eb.Holes()
+
+	addrWidth := m.addressWidth()
 	var sbAddr strings.Builder
 	var sbHex strings.Builder
 	var sbAscii strings.Builder
 	for row := 0; row < m.nrows; row++ {
+		textSkip := 0
+
 		// Address column
-		sbAddr.WriteString(addrStyle.Render(fmt.Sprintf("%08x", m.ncols*(row+m.viewRow))))
+		sbAddr.WriteString(addrStyle.Render(m.formatAddress(int64(m.ncols*(row+m.viewRow)), addrWidth)))
 
 		for col := 0; col < m.ncols; col++ {
 			i := row*m.ncols + col
 			pos := int64(i) + offset
-			if col%8 == 0 {
-				sbHex.WriteString(" ")
-			}
 
 			// Check for any active regions at this position
 			activeRegions := core.GetActiveRegions(regions, pos)
 
+			// Tint bytes by their class
+			hole := core.IsHole(holes, pos)
+			class := util.ByteClassNone
+			if m.colorBytes && i < n && !hole {
+				class = util.ClassifyByte(buf[i])
+			}
+
 			// Highlight selection
 			isEditing := m.mode == ModeInsert || m.mode == ModeReplace
-			styleHex := MakeStyle(m.activeColumn == ActiveColumnHex, isEditing, activeRegions)
-			styleAscii := MakeStyle(m.activeColumn == ActiveColumnAscii, isEditing, activeRegions)
+			styleAscii := MakeStyle(m.activeColumn == ActiveColumnAscii, isEditing, class, activeRegions)
 
-			// Hex column
-			if i >= n {
-				sbHex.WriteString(styleHex.Render("  "))
-			} else {
-				sbHex.WriteString(styleHex.Render(fmt.Sprintf("%02x ", buf[i])))
+			// Hex column, with an extra space every 8 bytes
+			if col%m.group == 0 {
+				if col == 0 || (col%8 == 0 && m.group < 8) {
+					sbHex.WriteString(" ")
+				}
+				sbHex.WriteString(m.renderCell(buf[:n], i, pos, regions, holes, isEditing))
 			}
 
-			// ASCII column
-			if i >= n {
+			// Text column
+			if textSkip > 0 {
+				// Covered by the previous multi-byte character
+				textSkip--
+			} else if i >= n {
 				sbAscii.WriteString(" ")
-			} else if buf[i] >= 32 && buf[i] <= 126 {
-				sbAscii.WriteString(styleAscii.Render(string(buf[i])))
+			} else if hole {
+				sbAscii.WriteString(styleAscii.Render(" "))
 			} else {
-				sbAscii.WriteString(styleAscii.Render("."))
+				text, size := m.decodeText(buf[i:util.Min(n, (row+1)*m.ncols)], pos)
+				if size > 1 {
+					// Highlight the whole character if any of its bytes are
+					for j := 1; j < size; j++ {
+						activeRegions = append(activeRegions, core.GetActiveRegions(regions, pos+int64(j))...)
+					}
+					styleAscii = MakeStyle(m.activeColumn == ActiveColumnAscii, isEditing, class, activeRegions)
+				}
+				sbAscii.WriteString(styleAscii.Render(text))
+				textSkip = size - 1
 			}
 		}
 
@@ -78,48 +110,173 @@ func (m Model) RenderHexView() (string, error) {
 		}
 	}
 
-	// Inspector
-	var inspTable string
-	if m.inspectorEnabled && m.eb.Cursor >= offset && m.eb.Cursor < offset+int64(m.nrows*m.ncols) {
-		var sbInsK strings.Builder
-		var sbInsV strings.Builder
-		inspOffset := int64(m.eb.Cursor) - offset
-		insp := util.Inspect(buf[inspOffset:], m.inspectorByteOrder)
-		for i, r := range insp {
+	view := lipgloss.JoinHorizontal(lipgloss.Top,
+		sbAddr.String(),
+		sbHex.String(),
+		sbAscii.String(),
+	)
+	if m.hidePanels {
+		return view, nil
+	}
+
+	// Panels, such as the inspector
+	ed := &pluginEditor{m: &m}
+	views := []string{view}
+	frame := padLeftStyle.GetHorizontalFrameSize() + windowStyle.GetHorizontalFrameSize()
+	width := util.Max(m.width-lipgloss.Width(view)-frame, 0)
+	height := util.Max(m.nrows-3, 1)
+	for _, p := range panels() {
+		body := p.Render(ed, width, height)
+		if body == "" {
+			continue
+		}
+
+		title := windowTitleStyle.Render(p.Name)
+		if p.Status != nil {
+			title = lipgloss.JoinHorizontal(lipgloss.Top, title, statusBarStyle.Render(p.Status(ed)))
+		}
+		views = append(views, padLeftStyle.Render(
+			lipgloss.JoinVertical(lipgloss.Left, title, windowStyle.Render(body)),
+		))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, views...), nil
+}
+
+// inspectorStatus returns the byte order shown next to the inspector's title.
+func inspectorStatus(ed plugin.Editor) string {
+	e, ok := ed.(*pluginEditor)
+	if ok && e.m.inspectorByteOrder == binary.BigEndian {
+		return " BE "
+	}
+	return " LE "
+}
+
+// renderInspector renders the inspector rows of the bytes at the cursor. The
+// inspector is hidden when it is disabled or the cursor is out of view.
+func renderInspector(ed plugin.Editor, width, height int) string {
+	e, ok := ed.(*pluginEditor)
+	if !ok {
+		return ""
+	}
+	m := e.m
+	viewStart, viewEnd := m.GetViewBounds()
+	if !m.inspectorEnabled || m.eb.Cursor < viewStart || m.eb.Cursor >= viewEnd {
+		return ""
+	}
+
+	var sbInsK strings.Builder
+	var sbInsV strings.Builder
+	insp := m.inspectCursor()
+
+	// Scroll the rows to keep the selected row visible
+	first, last := 0, util.Min(len(insp), height)
+	if m.activeColumn == ActiveColumnInspector && m.inspectorRow >= last {
+		first, last = m.inspectorRow-last+1, m.inspectorRow+1
+	}
+
+	selectedStyle := MakeStyle(true, false, util.ByteClassNone, []core.Region{{Type: core.RegionTypeCursor}})
+	for i := first; i < last && i < len(insp); i++ {
+		r := insp[i]
+		if m.activeColumn == ActiveColumnInspector && i == m.inspectorRow {
+			sbInsK.WriteString(selectedStyle.Render(r.Key))
+			sbInsV.WriteString(selectedStyle.Render(r.Val))
+		} else {
 			sbInsK.WriteString(r.Key)
 			sbInsV.WriteString(r.Val)
-			if i < len(insp)-1 {
-				sbInsK.WriteString("  \n")
-				sbInsV.WriteString("\n")
-			}
 		}
-		byteOrderDisp := " LE "
-		if m.inspectorByteOrder == binary.BigEndian {
-			byteOrderDisp = " BE "
+		if i < last-1 {
+			sbInsK.WriteString("  \n")
+			sbInsV.WriteString("\n")
 		}
-		inspTable =
-			padLeftStyle.Render(
-				lipgloss.JoinVertical(lipgloss.Left,
-					lipgloss.JoinHorizontal(lipgloss.Top,
-						windowTitleStyle.Render("Inspector"),
-						statusBarStyle.Render(byteOrderDisp),
-					),
-					windowStyle.Render(
-						lipgloss.JoinHorizontal(lipgloss.Top,
-							sbInsK.String(),
-							sbInsV.String(),
-						),
-					),
-				),
-			)
 	}
 
 	return lipgloss.JoinHorizontal(lipgloss.Top,
-		sbAddr.String(),
-		sbHex.String(),
-		sbAscii.String(),
-		inspTable,
-	), nil
+		sbInsK.String(),
+		sbInsV.String(),
+	)
+}
+
+// renderCell renders the group of bytes starting at index i of buf, which is
+// at position pos in the buffer. In the hex format, each byte is styled
+// separately. In other formats, the whole group is styled as one. Bytes in
+// holes are shown as dashes.
+func (m Model) renderCell(buf []byte, i int, pos int64, regions []core.Region, holes []core.Range, isEditing bool) string {
+	primary := m.activeColumn == ActiveColumnHex
+
+	if m.cellFormat == util.CellHex {
+		var sb strings.Builder
+		for k := 0; k < m.group; k++ {
+			// Display little endian groups with the most significant byte first
+			j := k
+			if m.groupByteOrder == binary.LittleEndian {
+				j = m.group - 1 - k
+			}
+
+			text := "  "
+			class := util.ByteClassNone
+			if core.IsHole(holes, pos+int64(j)) {
+				text = "--"
+			} else if i+j < len(buf) {
+				text = fmt.Sprintf("%02x", buf[i+j])
+				if m.colorBytes {
+					class = util.ClassifyByte(buf[i+j])
+				}
+			}
+			if k == m.group-1 {
+				text += " "
+			}
+
+			activeRegions := core.GetActiveRegions(regions, pos+int64(j))
+			sb.WriteString(MakeStyle(primary, isEditing, class, activeRegions).Render(text))
+		}
+		return sb.String()
+	}
+
+	// Highlight the whole group if any of its bytes are
+	activeRegions := make([]core.Region, 0)
+	hole := false
+	for j := 0; j < m.group; j++ {
+		activeRegions = append(activeRegions, core.GetActiveRegions(regions, pos+int64(j))...)
+		hole = hole || core.IsHole(holes, pos+int64(j))
+	}
+
+	width := util.CellWidth(m.cellFormat, m.group)
+	text := strings.Repeat(" ", width)
+	class := util.ByteClassNone
+	if i+m.group <= len(buf) && hole {
+		text = strings.Repeat("-", width)
+	} else if i+m.group <= len(buf) {
+		text = util.FormatCell(buf[i:i+m.group], m.cellFormat, m.groupByteOrder)
+		if m.colorBytes && m.group == 1 {
+			class = util.ClassifyByte(buf[i])
+		}
+	}
+
+	return MakeStyle(primary, isEditing, class, activeRegions).Render(text + " ")
+}
+
+// decodeText decodes the character at the start of buf, which is at position
+// pos in the buffer, using the current encoding. It returns the text to
+// display and the number of bytes covered by it. Characters that are narrower
+// than their size in bytes are padded with spaces, so that the text column
+// stays aligned with the hex column.
+func (m Model) decodeText(buf []byte, pos int64) (string, int) {
+	if pos%int64(m.charset.Align) != 0 {
+		return ".", 1
+	}
+
+	r, size := m.charset.Decode(buf)
+	if r == utf8.RuneError {
+		return ".", 1
+	}
+
+	width := runewidth.RuneWidth(r)
+	if width < 1 || width > size {
+		return ".", 1
+	}
+
+	return string(r) + strings.Repeat(" ", size-width), size
 }
 
 // RenderStatus renders the status bar.
@@ -140,6 +297,21 @@ func (m Model) RenderStatus() string {
 	}
 	sb.WriteString(statusBarStyle.Render(fname))
 
+	// Checksum rules
+	if len(m.checksums) > 0 {
+		if bad := m.badChecksums(); bad > 0 {
+			sb.WriteString(textErrorStyle.Inherit(statusBarStyle).Render(
+				fmt.Sprintf(" checksums bad (%d/%d)", bad, len(m.checksums))))
+		} else {
+			sb.WriteString(statusBarStyle.Render(" checksums ok"))
+		}
+	}
+
+	// Macro recording indicator
+	if m.recording != 0 {
+		sb.WriteString(statusBarStyle.Render(fmt.Sprintf(" recording @%c", m.recording)))
+	}
+
 	sb.WriteString("\n")
 	if m.statusError {
 		sb.WriteString(textErrorStyle.Render(m.cmdText.View()))
@@ -150,16 +322,60 @@ func (m Model) RenderStatus() string {
 	return sb.String()
 }
 
+// addressWidth returns the width of the address column, which is wide enough
+// to display the address of the end of the buffer, and at least 8 characters.
+func (m Model) addressWidth() int {
+	end := m.addrBase
+	if m.eb != nil {
+		end += uint64(m.eb.Size())
+	}
+	return util.Max(len(m.formatAddress(int64(end-m.addrBase), 0)), 8)
+}
+
+// formatAddress formats the address of the byte at the given offset, padded
+// to the given width.
+func (m Model) formatAddress(offset int64, width int) string {
+	if m.addrDecimal {
+		return fmt.Sprintf("%0*d", width, m.addrBase+uint64(offset))
+	}
+	return fmt.Sprintf("%0*x", width, m.addrBase+uint64(offset))
+}
+
+// addressBase returns the base in which addresses are shown and typed.
+func (m Model) addressBase() int {
+	if m.addrDecimal {
+		return 10
+	}
+	return 16
+}
+
+// parseAddress parses an address as displayed in the address column and
+// returns its offset in the buffer. Addresses are read in the address format
+// unless they have a base prefix such as 0x.
+func (m Model) parseAddress(s string) (int64, error) {
+	addr, err := util.ParseNumber(s, m.addressBase())
+	if err != nil {
+		return 0, err
+	}
+	if addr < m.addrBase || addr-m.addrBase > uint64(m.eb.Size()) {
+		return 0, fmt.Errorf("address %s out of range", s)
+	}
+	return int64(addr - m.addrBase), nil
+}
+
 // CalculateViewSize calculates the number of rows and columns that can fit in
-// the given width and height.
-func CalculateViewSize(width, height int) (ncols, nrows int) {
-	// 8 chars for the address + 2 padding
-	// 3 chars for each hex value + 1 padding every 8 chars
-	// 1 char for each ASCII value
-	ncols = (width - 8 - 2) / (3 + 1)
-
-	// Round down to nearest multiple of 8
-	ncols = ncols - ncols%8
+// the given width and height, using the current group size and cell format.
+func (m Model) CalculateViewSize(width, height int) (ncols, nrows int) {
+	// The address + 2 padding
+	// Each group of 8 bytes takes one cell per group of bytes with 1 padding
+	// each, 1 padding between groups of 8 bytes, and 1 char for each byte in
+	// the text column
+	perGroupOf8 := (8/m.group)*(util.CellWidth(m.cellFormat, m.group)+1) + 8
+	if m.group < 8 {
+		perGroupOf8++
+	}
+	ncols = (width - m.addressWidth() - 2) / perGroupOf8 * 8
+	ncols = util.Max(ncols, 8)
 
 	// Allocate 2 rows for bottom status bar
 	nrows = height - 2
@@ -0,0 +1,173 @@
+package display
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// dumpChunkRows is the number of rows rendered at a time by Dump.
+const dumpChunkRows = 256
+
+// Dump writes the hex view of the whole buffer to w, without the cursor, the
+// panels and trailing spaces.
+func (m Model) Dump(w io.Writer) error {
+	m.hidePanels = true
+
+	// Move the cursor out of the view while rendering
+	cursor, selectionStart := m.eb.Cursor, m.eb.SelectionStart
+	m.eb.Cursor, m.eb.SelectionStart = -1, -1
+	defer func() {
+		m.eb.Cursor, m.eb.SelectionStart = cursor, selectionStart
+	}()
+
+	totalRows := int((m.eb.Size() + int64(m.ncols) - 1) / int64(m.ncols))
+	for row := 0; row < totalRows; row += dumpChunkRows {
+		m.viewRow = row
+		m.nrows = util.Min(dumpChunkRows, totalRows-row)
+
+		view, err := m.RenderHexView()
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(view, "\n") {
+			if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeEditableDump writes the buffer as a hex dump in the layout of
+// hexdump -C. The delimited text column lets lines be edited to any length.
+func (m Model) writeEditableDump(w io.Writer) error {
+	r := m.eb.ReadSeeker()
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, m.ncols)
+	for offset := int64(0); ; offset += int64(m.ncols) {
+		n, err := io.ReadFull(r, buf)
+		if n == 0 {
+			break
+		}
+
+		var hexCol, textCol strings.Builder
+		for i := 0; i < m.ncols; i++ {
+			if i > 0 && i%8 == 0 {
+				hexCol.WriteByte(' ')
+			}
+			if i >= n {
+				hexCol.WriteString("   ")
+				continue
+			}
+			fmt.Fprintf(&hexCol, "%02x ", buf[i])
+			if buf[i] >= 0x20 && buf[i] < 0x7f {
+				textCol.WriteByte(buf[i])
+			} else {
+				textCol.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(bw, "%08x  %s |%s|\n", offset, hexCol.String(), textCol.String())
+
+		if err != nil {
+			break
+		}
+	}
+	return bw.Flush()
+}
+
+// editDump writes a hex dump of the buffer to a temporary file and opens it
+// in the user's editor. When the editor exits, the edited dump is applied to
+// the buffer.
+func editDump(m Model) (Model, tea.Cmd) {
+	f, err := os.CreateTemp("", "gex-*.txt")
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	defer f.Close()
+
+	if err := m.writeEditableDump(f); err != nil {
+		os.Remove(f.Name())
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	path := f.Name()
+	c := exec.Command(editor[0], append(editor[1:], path)...)
+	return m, tea.ExecProcess(c, func(err error) tea.Msg {
+		return DumpEditedMsg{Path: path, Err: err}
+	})
+}
+
+// applyDump parses the edited dump and replaces the part of the buffer that
+// differs from it with a single change.
+func (m *Model) applyDump(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// Lines are joined in order, so that bytes can be inserted and deleted
+	// without fixing the offsets
+	chunks, err := util.ParseEditableDump(f, m.ncols)
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, 0)
+	for _, c := range chunks {
+		data = append(data, c.Data...)
+	}
+
+	r := m.eb.ReadSeeker()
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	old, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	// Find the common prefix and suffix
+	prefix := 0
+	for prefix < len(old) && prefix < len(data) && old[prefix] == data[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(data)-prefix && old[len(old)-1-suffix] == data[len(data)-1-suffix] {
+		suffix++
+	}
+	if prefix == len(old) && prefix == len(data) {
+		return "No changes", nil
+	}
+
+	m.eb.PreviewChange(&core.Change{
+		Position: int64(prefix),
+		Removed:  int64(len(old) - prefix - suffix),
+		Data:     data[prefix : len(data)-suffix],
+	})
+	m.eb.CommitChange()
+	m.SetCursor(int64(prefix))
+	m.eb.SelectionStart = m.eb.Cursor
+
+	return fmt.Sprintf("Replaced %d bytes with %d bytes at %s", len(old)-prefix-suffix, len(data)-prefix-suffix, m.formatAddress(int64(prefix), 0)), nil
+}
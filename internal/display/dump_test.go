@@ -0,0 +1,33 @@
+package display
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyDump(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newTestModel("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	m.ncols = 16
+	var buf bytes.Buffer
+	if !assert.NoError(m.writeEditableDump(&buf)) {
+		return
+	}
+
+	// Insert a byte in the middle of a full line and one at its end, leaving
+	// the text column as it was
+	dump := strings.Replace(buf.String(), "48  49", "48  ff 49", 1)
+	dump = strings.Replace(dump, "4f 50  |", "4f 50 fe  |", 1)
+	path := filepath.Join(t.TempDir(), "dump.txt")
+	assert.NoError(os.WriteFile(path, []byte(dump), 0644))
+
+	_, err := m.applyDump(path)
+	assert.NoError(err)
+	assert.Equal("ABCDEFGH\xffIJKLMNOP\xfeQRSTUVWXYZ", testContents(m))
+}
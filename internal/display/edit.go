@@ -0,0 +1,127 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// maxGrowth is the largest number of bytes that insert and resize add at once,
+// as the bytes are held in memory until the buffer is saved.
+const maxGrowth = 1 << 30
+
+// handleFillCommand overwrites the selection, or the byte at the cursor, with
+// a repeating pattern.
+func handleFillCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: fill <pattern>"})
+	}
+	pattern, err := util.ParsePattern(strings.Join(args, " "))
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if m.eb.Size() == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Buffer is empty", Error: true})
+	}
+
+	start, end := m.eb.GetSelectionRange()
+	n := end - start + 1
+	if err := m.commitChange(core.Change{
+		Position: start,
+		Removed:  n,
+		Data:     util.RepeatPattern(pattern, int(n)),
+	}); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Filled %d bytes", n)})
+}
+
+// handleInsertCommand inserts count bytes of a repeating pattern at the
+// cursor. The pattern defaults to zeros.
+func handleInsertCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: insert <count> [pattern]"})
+	}
+	count, err := util.ParseSize(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if count > maxGrowth {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Cannot insert more than %d bytes at once", maxGrowth), Error: true})
+	}
+	pattern := []byte{0}
+	if len(args) > 1 {
+		if pattern, err = util.ParsePattern(strings.Join(args[1:], " ")); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+	}
+
+	if err := m.commitChange(core.Change{
+		Position: m.eb.Cursor,
+		Data:     util.RepeatPattern(pattern, int(count)),
+	}); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Inserted %d bytes", count)})
+}
+
+// handleTruncateCommand removes everything from the address, or the cursor,
+// to the end of the buffer.
+func handleTruncateCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	offset := m.eb.Cursor
+	if len(args) > 0 {
+		var err error
+		if offset, err = m.parseAddress(args[0]); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+	}
+	return m.resize(offset, 0)
+}
+
+// handleResizeCommand truncates the buffer or pads it with a byte, which
+// defaults to zero, to the given size.
+func handleResizeCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 || len(args) > 2 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: resize <size> [byte]"})
+	}
+	size, err := util.ParseSize(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if size > uint64(m.eb.Size())+maxGrowth {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Cannot grow the buffer by more than %d bytes at once", maxGrowth), Error: true})
+	}
+	pad := byte(0)
+	if len(args) > 1 {
+		v, err := util.ParseNumber(args[1], 16)
+		if err != nil || v > 0xff {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Invalid byte: " + args[1], Error: true})
+		}
+		pad = byte(v)
+	}
+	return m.resize(int64(size), pad)
+}
+
+// resize truncates or pads the buffer to size bytes as a single change.
+func (m Model) resize(size int64, pad byte) (Model, tea.Cmd) {
+	cur := m.eb.Size()
+	var chg core.Change
+	switch {
+	case size < cur:
+		chg = core.Change{Position: size, Removed: cur - size}
+	case size > cur:
+		chg = core.Change{Position: cur, Data: util.RepeatPattern([]byte{pad}, int(size-cur))}
+	default:
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Size is already %d bytes", size)})
+	}
+
+	if err := m.commitChange(chg); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	m.SetCursor(m.eb.Cursor)
+	m.eb.SelectionStart = util.Min(m.eb.SelectionStart, m.eb.Cursor)
+	return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Resized from %d to %d bytes", cur, size)})
+}
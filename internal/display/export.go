@@ -0,0 +1,224 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/hexfile"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// erasedByte fills the gaps between the records of Intel HEX and S-record
+// files, as it is the value of erased flash.
+const erasedByte = 0xff
+
+// parseRange parses a range of the buffer and returns its start and end
+// offsets, with the end exclusive. A range is either "*" for the selection, or
+// two addresses separated by "..", where the end defaults to the end of the
+// buffer.
+func (m Model) parseRange(s string) (int64, int64, error) {
+	if s == "*" {
+		start, end := m.eb.GetSelectionRange()
+		return start, util.Min(end+1, m.eb.Size()), nil
+	}
+
+	from, to, ok := strings.Cut(s, "..")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range: %s", s)
+	}
+	start, err := m.parseAddress(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end := m.eb.Size()
+	if to != "" && !strings.EqualFold(to, "EOF") {
+		if end, err = m.parseAddress(to); err != nil {
+			return 0, 0, err
+		}
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("invalid range: %s", s)
+	}
+	return start, end, nil
+}
+
+// isRange returns whether an argument is a range rather than a file name,
+// which may contain ".." as well. Addresses are in the same base as in
+// parseRange.
+func (m Model) isRange(s string) bool {
+	if s == "*" {
+		return true
+	}
+	from, to, ok := strings.Cut(s, "..")
+	if !ok {
+		return false
+	}
+	if _, err := util.ParseNumber(from, m.addressBase()); err != nil {
+		return false
+	}
+	if to == "" || strings.EqualFold(to, "EOF") {
+		return true
+	}
+	_, err := util.ParseNumber(to, m.addressBase())
+	return err == nil
+}
+
+// writeRange writes a range of the buffer to a file.
+func (m Model) writeRange(rng, fileName string) (Model, tea.Cmd) {
+	start, end, err := m.parseRange(rng)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	data, err := m.readBytes(start, end-start)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Error saving: " + err.Error(), Error: true})
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Wrote %d bytes to %s", len(data), fileName)})
+}
+
+// insertAtCursor inserts data at the cursor as a single change.
+func (m Model) insertAtCursor(data []byte, text string) (Model, tea.Cmd) {
+	if err := m.commitChange(core.Change{Position: m.eb.Cursor, Data: data}); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: text})
+}
+
+// handleReadCommand inserts the contents of a file at the cursor.
+func handleReadCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) != 1 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: r <file>"})
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	return m.insertAtCursor(data, fmt.Sprintf("Read %d bytes from %s", len(data), args[0]))
+}
+
+// parseFormatArgs splits the arguments of export and import into the format,
+// the remaining arguments and the file name, which is always last. The format
+// defaults to the one matching the file's extension.
+func (m Model) parseFormatArgs(args []string) (*hexfile.Format, []string, string, error) {
+	fileName := args[len(args)-1]
+	args = args[:len(args)-1]
+	if len(args) > 0 && !m.isRange(args[0]) {
+		f, err := hexfile.Lookup(args[0])
+		return f, args[1:], fileName, err
+	}
+
+	f := hexfile.FormatForFile(fileName)
+	if f == nil {
+		return nil, nil, "", fmt.Errorf("unknown format for %s, expected one of: %s", fileName, strings.Join(hexfile.Names(), " "))
+	}
+	return f, args, fileName, nil
+}
+
+// handleExportCommand writes a range of the buffer, the selection or the
+// whole buffer to a file in a format such as Intel HEX or a C array.
+// Addresses in the file follow the addrbase option.
+func handleExportCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 || len(args) > 3 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: export [format] [range] <file>"})
+	}
+	if args[0] == "list" {
+		return m, TeaMsgCmd(StatusTextMsg{Text: strings.Join(hexfile.Names(), " ")})
+	}
+	f, args, fileName, err := m.parseFormatArgs(args)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	start, end := int64(0), m.eb.Size()
+	switch {
+	case len(args) > 1:
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: export [format] [range] <file>"})
+	case len(args) == 1:
+		if start, end, err = m.parseRange(args[0]); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+	case m.eb.SelectionStart != m.eb.Cursor:
+		start, end, _ = m.parseRange("*")
+	}
+	data, err := m.readBytes(start, end-start)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	defer file.Close()
+	segments := []hexfile.Segment{{Address: m.addrBase + uint64(start), Data: data}}
+	if f.ReadLayout != nil {
+		segments = m.leaveOutHoles(start, data)
+	}
+	if err := f.Write(file, hexfile.Identifier(fileName), segments); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("cannot export %s: %s", f.Name, err), Error: true})
+	}
+	if err := file.Close(); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Exported %d bytes to %s as %s", len(data), fileName, f.Name)})
+}
+
+// leaveOutHoles splits the data read from the offset into segments at the
+// holes of the buffer, leaving the holes out.
+func (m Model) leaveOutHoles(offset int64, data []byte) []hexfile.Segment {
+	end := offset + int64(len(data))
+	segments := make([]hexfile.Segment, 0, 1)
+	for _, e := range core.Extents(end, m.eb.Holes()) {
+		if e.End < offset {
+			continue
+		}
+		from := util.Max(e.Start, offset)
+		segments = append(segments, hexfile.Segment{
+			Address: m.addrBase + uint64(from),
+			Data:    data[from-offset : e.End+1-offset],
+		})
+	}
+	return segments
+}
+
+// handleImportCommand inserts the data of a file in a format such as Intel
+// HEX or a C array at the cursor. Gaps between the records of formats with
+// addresses are filled with 0xff.
+func handleImportCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 || len(args) > 2 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: import [format] <file>"})
+	}
+	f, args, fileName, err := m.parseFormatArgs(args)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if len(args) > 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: import [format] <file>"})
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	defer file.Close()
+	segments, err := f.Read(file)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("cannot import %s: %s", f.Name, err), Error: true})
+	}
+
+	base, data := hexfile.Flatten(segments, erasedByte)
+	text := fmt.Sprintf("Imported %d bytes from %s", len(data), fileName)
+	if len(segments) > 1 || base != 0 {
+		text += fmt.Sprintf(" at 0x%x..0x%x", base, base+uint64(len(data)))
+	}
+	if len(segments) > 1 {
+		text += fmt.Sprintf(" in %d segments", len(segments))
+	}
+	return m.insertAtCursor(data, text)
+}
@@ -0,0 +1,72 @@
+package display
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRange(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		decimal  bool
+		expected bool
+	}{
+		{"*", false, true},
+		{"10..20", false, true},
+		{"0x10..0x20", false, true},
+		{"10..", false, true},
+		{"10..EOF", false, true},
+		{"a..ff", false, true},
+		{"..", false, false},
+		{"..20", false, false},
+		{"../out.bin", false, false},
+		{"a/../out.bin", false, false},
+		{"out..bin", false, false},
+		{"out.bin", false, false},
+		{"10..20", true, true},
+		{"0x10..0x1f", true, true},
+		{"a..ff", true, false},
+		{"10..1f", true, false},
+	}
+
+	for _, test := range matrix {
+		m := newTestModel("")
+		m.addrDecimal = test.decimal
+		assert.Equal(test.expected, m.isRange(test.inp), "%s decimal=%v", test.inp, test.decimal)
+	}
+}
+
+func TestWriteCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	assert.NoError(os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	wd, err := os.Getwd()
+	if !assert.NoError(err) {
+		return
+	}
+	defer os.Chdir(wd)
+	assert.NoError(os.Chdir(filepath.Join(dir, "sub")))
+
+	m := newTestModel("abcdef")
+
+	// A relative path going up a directory is a file name, not a range
+	assert.NoError(m.ExecCommand("w ../out.bin"))
+	data, err := os.ReadFile(filepath.Join(dir, "out.bin"))
+	assert.NoError(err)
+	assert.Equal("abcdef", string(data))
+
+	assert.NoError(m.ExecCommand("w 1..3 ../part.bin"))
+	data, err = os.ReadFile(filepath.Join(dir, "part.bin"))
+	assert.NoError(err)
+	assert.Equal("bc", string(data))
+
+	assert.Error(m.ExecCommand("w 4..2 ../bad.bin"))
+	_, err = os.Stat(filepath.Join(dir, "bad.bin"))
+	assert.True(os.IsNotExist(err))
+}
@@ -0,0 +1,80 @@
+package display
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// hashRange writes n bytes at the offset of the buffer to the hash and
+// returns the sum.
+func (m Model) hashRange(h hash.Hash, offset, n int64) ([]byte, error) {
+	r := m.eb.ReadSeeker()
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(h, r, n); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// handleHashCommand computes a checksum or hash of the selection, or of the
+// whole buffer if nothing is selected, and optionally writes it into the
+// buffer.
+func handleHashCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) > 3 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: hash [algorithm] [address [le|be]]"})
+	}
+
+	algo := "crc32"
+	if len(args) > 0 {
+		algo = args[0]
+	}
+	if algo == "list" {
+		return m, TeaMsgCmd(StatusTextMsg{Text: strings.Join(util.HashNames(), " ")})
+	}
+	h, err := util.NewHash(algo)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	start, end := int64(0), m.eb.Size()-1
+	if m.eb.SelectionStart != m.eb.Cursor {
+		start, end = m.eb.GetSelectionRange()
+	}
+	sum, err := m.hashRange(h, start, end-start+1)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	text := fmt.Sprintf("%s of %d bytes at 0x%x: %x", algo, end-start+1, start, sum)
+	if len(args) < 2 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: text})
+	}
+
+	offset, err := m.parseAddress(args[1])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	order := m.inspectorByteOrder
+	if len(args) > 2 {
+		if order, err = util.ParseByteOrder(args[2]); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+	}
+
+	data := util.OrderSum(sum, order)
+	if err := m.commitChange(core.Change{
+		Position: offset,
+		Removed:  util.Clamp(m.eb.Size()-offset, 0, int64(len(data))),
+		Data:     data,
+	}); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("%s, written to 0x%x", text, offset)})
+}
@@ -1,126 +1,156 @@
 package display
 
 import (
-	"encoding/binary"
-	"encoding/hex"
 	"fmt"
-	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hizkifw/gex/pkg/util"
 )
 
+// handleCommand runs the command with the given name, looking it up in the
+// plugin registry before the built-in commands.
 func handleCommand(m Model, command string, args []string) (Model, tea.Cmd) {
-	// Execute the command
-	switch command {
-	case "q", "quit", "q!", "quit!":
-		if m.eb.IsDirty() && !strings.HasSuffix(command, "!") {
-			return m, TeaMsgCmd(StatusTextMsg{Text: "No write since last change (add ! to override)", Error: true})
-		}
-		return m, tea.Quit
-
-	case "w", "write", "wq":
-		// Save the buffer
-		fileName := m.eb.Name
-		overwrite := true
-		if len(args) > 0 {
-			fileName = args[0]
-			overwrite = false
-		}
+	cmd := lookupCommand(command)
+	if cmd == nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Unknown command: " + command, Error: true})
+	}
 
-		saveCmd := func() tea.Msg {
-			var n int64
-			var err error
-			if overwrite {
-				n, err = m.eb.Save(fileName)
-			} else {
-				n, err = m.eb.WriteToFile(fileName)
-			}
-			if err != nil {
-				return StatusTextMsg{Text: "Error saving: " + err.Error(), Error: true}
-			}
-
-			return BufferSavedMsg{FileName: fileName, BytesWritten: n, Quit: command == "wq"}
+	ed := &pluginEditor{m: &m}
+	err := cmd.Run(ed, append([]string{command}, args...))
+	return m, ed.result(err)
+}
+
+// handleQuitCommand quits the editor if there are no unsaved changes, or if
+// the command ends with '!'.
+func handleQuitCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if m.eb.IsDirty() && !strings.HasSuffix(command, "!") {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "No write since last change (add ! to override)", Error: true})
+	}
+	if len(m.parents) > 0 {
+		m.closeChild()
+		return m, nil
+	}
+	return m, tea.Quit
+}
+
+// handleWriteCommand saves the buffer, or writes it to another file if a name
+// is given. Given a range as well, only that range is written. A buffer
+// unpacked from another buffer is packed back into it, which needs a '!' if
+// the packed data no longer fits in place.
+func handleWriteCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	force := strings.HasSuffix(command, "!")
+	command = strings.TrimSuffix(command, "!")
+	if len(args) > 1 || len(args) == 1 && m.isRange(args[0]) {
+		if len(args) != 2 || command == "wq" {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: w <range> <file>"})
+		}
+		return m.writeRange(args[0], args[1])
+	}
+	if len(m.parents) > 0 && len(args) == 0 {
+		text, err := m.packIntoParent(force)
+		if err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+		if command == "wq" {
+			m.closeChild()
 		}
+		return m, TeaMsgCmd(StatusTextMsg{Text: text})
+	}
 
-		return m, tea.Batch(TeaMsgCmd(StatusTextMsg{Text: "Saving " + fileName}), saveCmd)
+	fileName := m.eb.Name
+	overwrite := true
+	if len(args) > 0 {
+		fileName = args[0]
+		overwrite = false
+	}
 
-	case "goto":
-		// Go to a specific byte offset
-		if len(args) == 0 {
-			return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: goto <offset(h)>"})
+	if m.fixChecksumsOnSave && len(m.checksums) > 0 {
+		if _, err := m.fixChecksums(); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Not saved: " + err.Error(), Error: true})
 		}
+	}
 
-		offsetHexStr := args[0]
-		if len(offsetHexStr)%2 != 0 {
-			offsetHexStr = "0" + offsetHexStr
+	saveCmd := func() tea.Msg {
+		var n int64
+		var err error
+		if overwrite {
+			n, err = m.eb.Save(fileName)
+		} else {
+			n, err = m.eb.WriteToFile(fileName)
 		}
-		offsetHex, err := hex.DecodeString(offsetHexStr)
 		if err != nil {
-			return m, TeaMsgCmd(StatusTextMsg{Text: "Invalid offset", Error: true})
-		}
-		if len(offsetHex) < 8 {
-			offsetHex = append(make([]byte, 8-len(offsetHex)), offsetHex...)
+			return StatusTextMsg{Text: "Error saving: " + err.Error(), Error: true}
 		}
 
-		offset := binary.BigEndian.Uint64(offsetHex)
-		if offset > uint64(m.eb.Size()) {
-			return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Offset %xh out of range", offset), Error: true})
-		}
+		return BufferSavedMsg{FileName: fileName, BytesWritten: n, Quit: command == "wq"}
+	}
 
-		m.SetCursor(int64(offset))
-		if m.prevMode != ModeVisual {
-			m.eb.SelectionStart = m.eb.Cursor
-		}
+	return m, tea.Batch(TeaMsgCmd(StatusTextMsg{Text: "Saving " + fileName}), saveCmd)
+}
 
-	case "set":
-		// Set a option
-		if len(args) < 2 {
-			return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: set <option> <value>"})
-		}
+// handleGotoCommand moves the cursor to an address.
+func handleGotoCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: goto <address>"})
+	}
 
-		option := args[0]
-		value := args[1]
-
-		switch option {
-		case "cols":
-			// Set the number of columns
-			cols, err := strconv.Atoi(value)
-			if err != nil {
-				return m, TeaMsgCmd(StatusTextMsg{Text: "Expected a number", Error: true})
-			}
-			m.ncols = cols
-			m.ScrollToCursor()
-
-		case "inspector.enabled":
-			// Enable/disable the inspector
-			enabled, err := strconv.ParseBool(value)
-			if err != nil {
-				return m, TeaMsgCmd(StatusTextMsg{Text: "Expected either true or false", Error: true})
-			}
-			m.inspectorEnabled = enabled
-
-		case "inspector.byteOrder":
-			// Set the inspector byte order
-			switch value {
-			case "big", "be", "b":
-				m.inspectorByteOrder = binary.BigEndian
-			case "little", "le", "l":
-				m.inspectorByteOrder = binary.LittleEndian
-			default:
-				return m, TeaMsgCmd(StatusTextMsg{Text: "Expected either b or l", Error: true})
-			}
-
-		default:
-			return m, TeaMsgCmd(StatusTextMsg{Text: "Unknown option: " + option, Error: true})
+	offset, err := m.parseAddress(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
 
+	m.SetCursor(offset)
+	if m.prevMode != ModeVisual {
+		m.eb.SelectionStart = m.eb.Cursor
+	}
+	return m, nil
+}
+
+// handleSetCommand sets or shows options.
+func handleSetCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	shown, err := m.applySetArgs(args)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: set <option>[=<value>]..."})
+	}
+	if shown != "" {
+		return m, TeaMsgCmd(StatusTextMsg{Text: shown})
+	}
+	return m, nil
+}
+
+// handleFtsetCommand sets options for a file type.
+func handleFtsetCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) < 2 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: ftset <filetype> <option>[=<value>]..."})
+	}
+
+	ft := strings.ToLower(strings.TrimPrefix(args[0], "."))
+	m.ftOptions[ft] = append(m.ftOptions[ft], args[1:]...)
+	if ft == fileType(m.eb.Name) {
+		if _, err := m.applySetArgs(args[1:]); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
 		}
+	}
+	return m, nil
+}
 
-	default:
-		return m, TeaMsgCmd(StatusTextMsg{Text: "Unknown command: " + command, Error: true})
+// handleColorschemeCommand loads a colour scheme, or shows the current one.
+func handleColorschemeCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf(
+			"%s (available: %s)", currentThemeName, strings.Join(listThemes(), ", "))})
 	}
 
+	theme, err := LoadTheme(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	applyTheme(theme)
+	currentThemeName = args[0]
 	return m, nil
 }
 
@@ -154,15 +184,18 @@ func HandleKeypressCommand(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 
 	// The "enter" key executes the command
 	case "enter":
-		m.cmdHistory = append(m.cmdHistory, m.cmdText.Value())
-		split := strings.Split(m.cmdText.Value(), " ")
-		command := split[0]
-		args := []string{}
-		if len(split) > 1 {
-			args = split[1:]
+		if handler := m.promptHandler; handler != nil {
+			input := m.cmdText.Value()
+			m.SetMode(m.prevMode)
+			return handler(m, input)
 		}
+
+		m.cmdHistory = append(m.cmdHistory, m.cmdText.Value())
+		command, args := splitCommand(m.cmdText.Value())
 		m, cmd = handleCommand(m, command, args)
-		m.SetMode(m.prevMode)
+		if m.promptHandler == nil {
+			m.SetMode(m.prevMode)
+		}
 
 	// Pass the keypress to the command text input
 	default:
@@ -171,11 +204,13 @@ func HandleKeypressCommand(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 
 		// If the command text input is empty, exit command mode
 		cmdVal := m.cmdText.Value()
-		if cmdVal == "goto g" {
-			m, cmd = handleCommand(m, "goto", []string{"00"})
+		if m.promptHandler != nil {
+			break
+		} else if cmdVal == "goto g" {
+			m, cmd = handleCommand(m, "goto", []string{m.formatAddress(0, 0)})
 			m.SetMode(m.prevMode)
 		} else if cmdVal == "goto G" {
-			m, cmd = handleCommand(m, "goto", []string{fmt.Sprintf("%x", m.eb.Size())})
+			m, cmd = handleCommand(m, "goto", []string{m.formatAddress(m.eb.Size(), 0)})
 			m.SetMode(m.prevMode)
 		}
 	}
@@ -25,6 +25,7 @@ func HandleKeypressInsert(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	}
 
 	// Pass inputs to the temporary text input
+	prevText := m.tmpText
 	m.tmpText, _ = m.tmpText.Update(msg)
 	tmpInput := m.tmpText.Value()
 	nBytes := int64(len(tmpInput))
@@ -32,19 +33,28 @@ func HandleKeypressInsert(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	removed := m.eb.Preview.Removed
 
 	if m.activeColumn == ActiveColumnAscii {
+		// Encode the text using the current encoding
+		data, err := m.charset.Encode(tmpInput)
+		if err != nil {
+			m.tmpText = prevText
+			m.StatusMessage(err.Error(), true)
+			return m, nil
+		}
+		prefix, _ := m.charset.Encode(string([]rune(tmpInput)[:m.tmpText.Position()]))
+
 		if m.mode == ModeReplace {
-			removed = util.Min(nBytes, bufLen-start)
+			removed = util.Min(int64(len(data)), bufLen-start)
 		}
 
 		// Update the cursor position
-		m.SetCursor(start + int64(m.tmpText.Position()))
+		m.SetCursor(start + int64(len(prefix)))
 		m.eb.SelectionStart = m.eb.Cursor
 
 		// Set the preview change
 		m.eb.PreviewChange(&core.Change{
 			Position: start,
 			Removed:  removed,
-			Data:     []byte(tmpInput),
+			Data:     data,
 		})
 	} else if m.activeColumn == ActiveColumnHex {
 		// Count number of bytes in the temporary input
@@ -0,0 +1,186 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/plugin"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// inspect returns the inspector rows of the bytes at the start of buf. The
+// built-in inspectors come first, unless a plugin inspector of the same name
+// replaces them.
+func (m Model) inspect(buf []byte) []util.Row {
+	rows := make([]util.Row, 0)
+	for _, name := range builtins.InspectorNames() {
+		fn := builtins.Inspector(name)
+		if override := plugin.Default.Inspector(name); override != nil {
+			fn = override
+		}
+		rows = append(rows, fn(buf, m.inspectorByteOrder)...)
+	}
+	for _, name := range plugin.Default.InspectorNames() {
+		if builtins.Inspector(name) == nil {
+			rows = append(rows, plugin.Default.Inspector(name)(buf, m.inspectorByteOrder)...)
+		}
+	}
+	for _, c := range m.customRows {
+		if row, ok := c.Inspect(buf); ok {
+			rows = append(rows, row)
+		}
+	}
+	if m.bitFieldWidth > 0 {
+		rows = append(rows, util.InspectBitField(buf, m.inspectorByteOrder, m.bitFieldOffset, m.bitFieldWidth)...)
+	}
+	return rows
+}
+
+// inspectSize returns the number of bytes read by the inspector.
+func (m Model) inspectSize() int {
+	size := util.InspectSize
+	for _, c := range m.customRows {
+		size = util.Max(size, c.Width)
+	}
+	return size
+}
+
+// inspectCursor returns the inspector rows of the bytes at the cursor.
+func (m Model) inspectCursor() []util.Row {
+	buf := make([]byte, m.inspectSize())
+	r := m.eb.ReadSeeker()
+	if _, err := r.Seek(m.eb.Cursor, io.SeekStart); err != nil {
+		return nil
+	}
+	n, _ := io.ReadFull(r, buf)
+	return m.inspect(buf[:n])
+}
+
+// moveInspectorRow selects the next editable inspector row in the given
+// direction.
+func (m *Model) moveInspectorRow(rows []util.Row, delta int) {
+	for i := m.inspectorRow + delta; i >= 0 && i < len(rows); i += delta {
+		if rows[i].Encode != nil {
+			m.inspectorRow = i
+			return
+		}
+	}
+}
+
+// handleInspectorKey handles the keys that select and edit inspector rows
+// while the inspector is the active column. It returns false if the key is
+// not handled by the inspector.
+func handleInspectorKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	rows := m.inspectCursor()
+	m.inspectorRow = util.Clamp(m.inspectorRow, 0, util.Max(len(rows)-1, 0))
+
+	switch msg.String() {
+	case "j", "down":
+		m.moveInspectorRow(rows, 1)
+
+	case "k", "up":
+		m.moveInspectorRow(rows, -1)
+
+	case "enter", "i", "a", "R", "c":
+		if m.inspectorRow >= len(rows) || rows[m.inspectorRow].Encode == nil {
+			m.StatusMessage("Row cannot be edited", true)
+			break
+		}
+
+		row := rows[m.inspectorRow]
+		m.Prompt(fmt.Sprintf("%s (%s): ", row.Key, row.Val), func(m Model, input string) (Model, tea.Cmd) {
+			return m.writeInspectorRow(row, input)
+		})
+
+	default:
+		return m, nil, false
+	}
+
+	return m, nil, true
+}
+
+// handleInspectorCommand adds, removes or lists custom inspector rows.
+func handleInspectorCommand(m Model, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 || args[0] == "list" {
+		if len(m.customRows) == 0 {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "No custom inspector rows"})
+		}
+		rows := make([]string, len(m.customRows))
+		for i, c := range m.customRows {
+			rows[i] = fmt.Sprintf("%s (%d)", c.Name, c.Width)
+		}
+		return m, TeaMsgCmd(StatusTextMsg{Text: strings.Join(rows, ", ")})
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 5 {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: inspector add <name> <width> <le|be> <template>"})
+		}
+
+		width, err := strconv.Atoi(args[2])
+		if err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Invalid width: " + args[2], Error: true})
+		}
+		order, err := util.ParseByteOrder(args[3])
+		if err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+		row, err := util.NewCustomRow(args[1], width, order, strings.Join(args[4:], " "))
+		if err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+
+		// Replace the row with the same name, if any
+		rows := make([]*util.CustomRow, 0, len(m.customRows)+1)
+		for _, c := range m.customRows {
+			if c.Name != row.Name {
+				rows = append(rows, c)
+			}
+		}
+		m.customRows = append(rows, row)
+
+	case "remove", "rm":
+		if len(args) < 2 {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: inspector remove <name>"})
+		}
+
+		rows := make([]*util.CustomRow, 0, len(m.customRows))
+		for _, c := range m.customRows {
+			if c.Name != args[1] {
+				rows = append(rows, c)
+			}
+		}
+		if len(rows) == len(m.customRows) {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "No such inspector row: " + args[1], Error: true})
+		}
+		m.customRows = rows
+
+	default:
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Unknown inspector command: " + args[0], Error: true})
+	}
+
+	return m, nil
+}
+
+// writeInspectorRow encodes the value with the row's encoder and overwrites
+// the bytes at the cursor with the result. Values that do not fit before the
+// end of the buffer are rejected rather than growing it.
+func (m Model) writeInspectorRow(row util.Row, input string) (Model, tea.Cmd) {
+	data, err := row.Encode(input)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if left := m.eb.Size() - m.eb.Cursor; int64(len(data)) > left {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("%s needs %d bytes, but only %d are left", row.Key, len(data), left), Error: true})
+	}
+
+	if err := m.commitChange(core.Change{Position: m.eb.Cursor, Removed: int64(len(data)), Data: data}); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	return m, nil
+}
@@ -0,0 +1,54 @@
+package display
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteInspectorRow(t *testing.T) {
+	assert := assert.New(t)
+
+	row := util.Row{Key: "test", Encode: func(s string) ([]byte, error) { return []byte(s), nil }}
+	isError := func(cmd tea.Cmd) bool {
+		if cmd == nil {
+			return false
+		}
+		msg, ok := cmd().(StatusTextMsg)
+		return ok && msg.Error
+	}
+
+	var matrix = []struct {
+		cursor   int64
+		input    string
+		expError bool
+		expData  string
+	}{
+		{0, "XY", false, "XYcdef"},
+		{4, "XY", false, "abcdXY"},
+		{5, "XY", true, "abcdef"},
+		{2, "WXYZ", false, "abWXYZ"},
+		{3, "WXYZ", true, "abcdef"},
+	}
+
+	for _, test := range matrix {
+		m := newTestModel("abcdef")
+		m.eb.Cursor = test.cursor
+		m, cmd := m.writeInspectorRow(row, test.input)
+		assert.Equal(test.expError, isError(cmd), test.input)
+		assert.Equal(test.expData, testContents(m), test.input)
+	}
+
+	// Writes are refused while typing in insert mode, like other changes
+	m := newTestModel("abcdef")
+	for _, k := range []string{"i", "5", "8"} {
+		model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = model.(Model)
+	}
+	m, cmd := m.writeInspectorRow(row, "Z")
+	assert.True(isError(cmd))
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEscape})
+	assert.Equal("Xabcdef", testContents(model.(Model)))
+}
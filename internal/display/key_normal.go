@@ -8,6 +8,25 @@ import (
 func HandleKeypressNormal(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	key := msg.String()
 
+	// Keys such as q and @ expect a register name to follow
+	if m.pendingKey != "" {
+		return handlePendingKey(m, msg)
+	}
+
+	// Accumulate the count prefix. A leading 0 is a movement key.
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || m.count > 0) {
+		m.count = m.count*10 + int(key[0]-'0')
+		return m, nil
+	}
+
+	// Keys that select and edit inspector rows
+	if m.activeColumn == ActiveColumnInspector && m.inspectorEnabled {
+		if m, cmd, ok := handleInspectorKey(m, msg); ok {
+			m.count = 0
+			return m, cmd
+		}
+	}
+
 	switch key {
 
 	case "i", "a":
@@ -50,11 +69,27 @@ func HandleKeypressNormal(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 			m.StatusMessage("Already at newest change", false)
 		}
 
+	case "q":
+		// Start or stop recording a macro
+		if m.recording != 0 {
+			m.StopRecording()
+		} else {
+			m.pendingKey = key
+		}
+
+	case "@":
+		// Play a macro, keeping the count for the register name
+		m.pendingKey = key
+		return m, nil
+
 	case "tab":
-		// Toggle active column
-		if m.activeColumn == ActiveColumnHex {
+		// Cycle through the hex, text and inspector columns
+		switch {
+		case m.activeColumn == ActiveColumnHex:
 			m.activeColumn = ActiveColumnAscii
-		} else {
+		case m.activeColumn == ActiveColumnAscii && m.inspectorEnabled:
+			m.activeColumn = ActiveColumnInspector
+		default:
 			m.activeColumn = ActiveColumnHex
 		}
 	}
@@ -62,6 +97,7 @@ func HandleKeypressNormal(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	m, _ = handleAction(m, msg)
 	m, _ = handleCursorMovement(m, msg)
 	m.eb.SelectionStart = m.eb.Cursor
+	m.count = 0
 
 	return m, nil
 }
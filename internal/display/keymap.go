@@ -0,0 +1,388 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/exp/slices"
+)
+
+// maxMapDepth limits how deeply mappings may expand into other mappings.
+const maxMapDepth = 100
+
+// maxMapExpansions limits how many mappings a keypress may expand into, as
+// mappings that use their own keys more than once grow exponentially.
+const maxMapExpansions = 10000
+
+// keyMapping maps a sequence of keys to another sequence of keys.
+type keyMapping struct {
+	// lhs is the sequence of key names, as returned by tea.KeyMsg.String(),
+	// that triggers the mapping.
+	lhs []string
+	// rhs is the sequence of keys the mapping expands to.
+	rhs []tea.KeyMsg
+	// noremap prevents the keys in rhs from being mapped again.
+	noremap bool
+}
+
+// keyTypes maps key names, as returned by tea.KeyType.String(), to key types.
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-256); t < 256; t++ {
+		if name := t.String(); name != "" && t != tea.KeyRunes {
+			types[name] = t
+		}
+	}
+	return types
+}()
+
+// keyNotation maps vi-style key names to names used by tea.KeyMsg.String().
+var keyNotation = map[string]string{
+	"esc":      "esc",
+	"cr":       "enter",
+	"enter":    "enter",
+	"return":   "enter",
+	"tab":      "tab",
+	"s-tab":    "shift+tab",
+	"bs":       "backspace",
+	"space":    " ",
+	"up":       "up",
+	"down":     "down",
+	"left":     "left",
+	"right":    "right",
+	"home":     "home",
+	"end":      "end",
+	"pageup":   "pgup",
+	"pagedown": "pgdown",
+	"del":      "delete",
+	"insert":   "insert",
+}
+
+// parseKeys parses a sequence of keys in vi notation, such as "gg" or
+// ":goto 0<CR>". Special keys are written in angle brackets, for example
+// <Esc>, <C-d>, <A-x>, <Space> and <lt> for a literal <.
+func parseKeys(s string) ([]tea.KeyMsg, error) {
+	keys := make([]tea.KeyMsg, 0, len(s))
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		end := -1
+		if r == '<' {
+			end = slices.Index(runes[i:], '>')
+		}
+		if end <= 1 {
+			// Regular character
+			if r == ' ' {
+				keys = append(keys, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+			} else {
+				keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			}
+			continue
+		}
+
+		key, err := parseSpecialKey(string(runes[i+1 : i+end]))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		i += end
+	}
+
+	return keys, nil
+}
+
+// parseSpecialKey parses the name of a key written in angle brackets.
+func parseSpecialKey(name string) (tea.KeyMsg, error) {
+	lower := strings.ToLower(name)
+
+	switch lower {
+	case "lt":
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'<'}}, nil
+	case "bar":
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'|'}}, nil
+	case "space":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, nil
+	}
+
+	// Alt modifier
+	for _, prefix := range []string{"a-", "m-", "alt+"} {
+		rest, ok := strings.CutPrefix(lower, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if len([]rune(rest)) == 1 {
+			return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name[len(prefix):]), Alt: true}, nil
+		}
+		key, err := parseSpecialKey(name[len(prefix):])
+		key.Alt = true
+		return key, err
+	}
+
+	// Control modifier
+	if rest, ok := strings.CutPrefix(lower, "c-"); ok {
+		lower = "ctrl+" + rest
+	}
+
+	if n, ok := keyNotation[lower]; ok {
+		lower = n
+	}
+	if t, ok := keyTypes[lower]; ok {
+		return tea.KeyMsg{Type: t}, nil
+	}
+
+	return tea.KeyMsg{}, fmt.Errorf("unknown key: <%s>", name)
+}
+
+// formatKeys formats a sequence of keys in vi notation.
+func formatKeys(keys []tea.KeyMsg) string {
+	var sb strings.Builder
+	for _, key := range keys {
+		switch {
+		case key.Type == tea.KeyRunes && !key.Alt:
+			sb.WriteString(strings.ReplaceAll(string(key.Runes), "<", "<lt>"))
+		case key.Type == tea.KeySpace:
+			sb.WriteString(" ")
+		default:
+			sb.WriteString("<" + specialKeyName(key) + ">")
+		}
+	}
+	return sb.String()
+}
+
+// specialKeyName returns the vi-style name of a special key.
+func specialKeyName(key tea.KeyMsg) string {
+	name := key.String()
+	prefix := ""
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok {
+		prefix, name = "A-", rest
+	}
+	if rest, ok := strings.CutPrefix(name, "ctrl+"); ok {
+		return prefix + "C-" + rest
+	}
+
+	for vi, n := range keyNotation {
+		if n == name && len(vi) > 1 && vi != "enter" && vi != "return" {
+			name = vi
+			break
+		}
+	}
+	if len(name) <= 2 {
+		return prefix + strings.ToUpper(name)
+	}
+	return prefix + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// keyNames returns the names of the keys, as returned by tea.KeyMsg.String().
+func keyNames(keys []tea.KeyMsg) []string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.String()
+	}
+	return names
+}
+
+// findMapping looks up the key sequence in the mappings for the mode. It
+// returns the mapping whose lhs equals the sequence, if any, and whether a
+// longer mapping starts with the sequence.
+func (m *Model) findMapping(mode EditingMode, seq []string) (*keyMapping, bool) {
+	var exact *keyMapping
+	prefix := false
+	for i, km := range m.keymaps[mode] {
+		if len(km.lhs) < len(seq) || !slices.Equal(km.lhs[:len(seq)], seq) {
+			continue
+		}
+		if len(km.lhs) == len(seq) {
+			exact = &m.keymaps[mode][i]
+		} else {
+			prefix = true
+		}
+	}
+	return exact, prefix
+}
+
+// feedKey applies key mappings to the keypress and passes the resulting keys
+// to the handler for the current mode. Keys that could start a mapping are
+// held back until the mapping can be resolved.
+func (m Model) feedKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	if len(m.keymaps[m.mode]) == 0 && len(m.pendingKeys) == 0 {
+		return m.handleKey(msg)
+	}
+
+	pending := append(m.pendingKeys, msg)
+	m.pendingKeys = nil
+	seq := keyNames(pending)
+
+	exact, prefix := m.findMapping(m.mode, seq)
+	if prefix {
+		// Wait for more keys
+		m.pendingKeys = pending
+		return m, nil
+	}
+	if exact != nil {
+		return m.expandMapping(exact, nil)
+	}
+
+	// No mapping matches the whole sequence. Use the longest mapping that
+	// matches the start of the sequence, or pass the first key through as-is,
+	// and feed the remaining keys again.
+	for n := len(seq) - 1; n > 0; n-- {
+		if km, _ := m.findMapping(m.mode, seq[:n]); km != nil {
+			return m.expandMapping(km, pending[n:])
+		}
+	}
+
+	m, cmd := m.handleKey(pending[0])
+	cmds := []tea.Cmd{cmd}
+	for _, key := range pending[1:] {
+		m, cmd = m.feedKey(key)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// expandMapping feeds the keys of the mapping, followed by the remaining keys
+// that were typed after the mapping. As in vi, if the keys of the mapping
+// start with the keys that trigger it, the first key is not mapped again.
+func (m Model) expandMapping(km *keyMapping, rest []tea.KeyMsg) (Model, tea.Cmd) {
+	if m.mapDepth == 0 {
+		m.mapExpansions = 0
+	}
+	if m.mapDepth >= maxMapDepth || m.mapExpansions >= maxMapExpansions {
+		// Stop the mappings being expanded as well
+		m.mapExpansions = maxMapExpansions + 1
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Recursive mapping", Error: true})
+	}
+	m.mapExpansions++
+
+	var cmd tea.Cmd
+	cmds := make([]tea.Cmd, 0, len(km.rhs)+len(rest))
+
+	selfFirst := len(km.rhs) >= len(km.lhs) && slices.Equal(keyNames(km.rhs[:len(km.lhs)]), km.lhs)
+	m.mapDepth++
+	for i, key := range km.rhs {
+		if m.mapExpansions > maxMapExpansions {
+			break
+		}
+		if km.noremap || (i == 0 && selfFirst) {
+			m, cmd = m.handleKey(key)
+		} else {
+			m, cmd = m.feedKey(key)
+		}
+		cmds = append(cmds, cmd)
+	}
+	m.mapDepth--
+
+	for _, key := range rest {
+		m, cmd = m.feedKey(key)
+		cmds = append(cmds, cmd)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// mapModes returns the modes affected by a map command.
+func mapModes(command string) []EditingMode {
+	prefix := command
+	for _, base := range []string{"noremap", "unmap", "map"} {
+		if p, ok := strings.CutSuffix(command, base); ok {
+			prefix = p
+			break
+		}
+	}
+
+	switch prefix {
+	case "n":
+		return []EditingMode{ModeNormal}
+	case "v":
+		return []EditingMode{ModeVisual}
+	}
+	return []EditingMode{ModeNormal, ModeVisual}
+}
+
+// modeAbbr returns the abbreviation used when listing mappings for the mode.
+func modeAbbr(mode EditingMode) string {
+	return strings.ToLower(string(mode[0]))
+}
+
+// handleMapCommand handles the map family of commands.
+func handleMapCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	modes := mapModes(command)
+
+	// List mappings
+	if len(args) == 0 {
+		list := make([]string, 0)
+		for _, mode := range modes {
+			for _, km := range m.keymaps[mode] {
+				lhs := formatKeys(keysFromNames(km.lhs))
+				list = append(list, fmt.Sprintf("%s %s %s", modeAbbr(mode), lhs, formatKeys(km.rhs)))
+			}
+		}
+		if len(list) == 0 {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "No mapping found"})
+		}
+		return m, TeaMsgCmd(StatusTextMsg{Text: strings.Join(list, "  ")})
+	}
+
+	lhsKeys, err := parseKeys(args[0])
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	lhs := keyNames(lhsKeys)
+
+	// Remove mappings
+	if strings.HasSuffix(command, "unmap") {
+		found := false
+		for _, mode := range modes {
+			n := len(m.keymaps[mode])
+			m.keymaps[mode] = slices.DeleteFunc(m.keymaps[mode], func(km keyMapping) bool {
+				return slices.Equal(km.lhs, lhs)
+			})
+			found = found || len(m.keymaps[mode]) != n
+		}
+		if !found {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "No such mapping", Error: true})
+		}
+		return m, nil
+	}
+
+	if len(args) < 2 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Usage: %s <lhs> <rhs>", command)})
+	}
+
+	rhs, err := parseKeys(strings.Join(args[1:], " "))
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	// Add or replace mappings
+	km := keyMapping{lhs: lhs, rhs: rhs, noremap: strings.Contains(command, "noremap")}
+	for _, mode := range modes {
+		i := slices.IndexFunc(m.keymaps[mode], func(other keyMapping) bool {
+			return slices.Equal(other.lhs, lhs)
+		})
+		if i >= 0 {
+			m.keymaps[mode][i] = km
+		} else {
+			m.keymaps[mode] = append(m.keymaps[mode], km)
+		}
+	}
+
+	return m, nil
+}
+
+// keysFromNames converts key names back into keys, for display purposes.
+func keysFromNames(names []string) []tea.KeyMsg {
+	keys := make([]tea.KeyMsg, len(names))
+	for i, name := range names {
+		if t, ok := keyTypes[name]; ok {
+			keys[i] = tea.KeyMsg{Type: t}
+		} else if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+			keys[i] = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(rest), Alt: true}
+		} else {
+			keys[i] = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+		}
+	}
+	return keys
+}
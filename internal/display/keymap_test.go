@@ -0,0 +1,47 @@
+package display
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecursiveMapping(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		mapping  string
+		expError bool
+		expMode  EditingMode
+	}{
+		// The first a is not mapped again, and enters insert mode
+		{"map a aa", false, ModeInsert},
+		{"map l ll", true, ModeNormal},
+		// Expands into twice as many mappings at each level
+		{"map x lxlx", true, ModeNormal},
+		{"noremap l ll", false, ModeNormal},
+	}
+
+	for _, test := range matrix {
+		m := newTestModel(strings.Repeat("x", 4096))
+		if !assert.NoError(m.ExecCommand(test.mapping), test.mapping) {
+			continue
+		}
+		keys := strings.Fields(test.mapping)[1]
+		var cmd tea.Cmd
+		for _, k := range keys {
+			var model tea.Model
+			model, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{k}})
+			m = model.(Model)
+		}
+		err := m.runCmdSync(cmd)
+		if test.expError {
+			assert.Error(err, test.mapping)
+		} else {
+			assert.NoError(err, test.mapping)
+		}
+		assert.Equal(test.expMode, m.mode, test.mapping)
+	}
+}
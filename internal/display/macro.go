@@ -0,0 +1,112 @@
+package display
+
+import (
+	"fmt"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// maxMacroDepth limits how deeply macros may invoke other macros, so that a
+// recursive macro cannot hang the editor.
+const maxMacroDepth = 100
+
+// registerName returns the register named by the keypress. Uppercase letters
+// refer to the same register as their lowercase counterparts, and the second
+// return value is true if the keypress is a valid register name.
+func registerName(msg tea.KeyMsg) (rune, bool) {
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 {
+		return 0, false
+	}
+
+	reg := msg.Runes[0]
+	if reg == '@' || (reg >= 'a' && reg <= 'z') || (reg >= 'A' && reg <= 'Z') || (reg >= '0' && reg <= '9') {
+		return reg, true
+	}
+	return 0, false
+}
+
+// handlePendingKey handles the keypress following a key that expects a
+// register name, such as q or @.
+func handlePendingKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	pending := m.pendingKey
+	count := util.Max(m.count, 1)
+	m.pendingKey = ""
+	m.count = 0
+
+	// Any key that is not a register name cancels the pending operation
+	reg, ok := registerName(msg)
+	if !ok {
+		return m, nil
+	}
+
+	switch pending {
+	case "q":
+		if reg == '@' {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Invalid register name", Error: true})
+		}
+		m.StartRecording(reg)
+
+	case "@":
+		return m.PlayMacro(reg, count)
+	}
+
+	return m, nil
+}
+
+// StartRecording starts recording keypresses into the given register. If the
+// register is an uppercase letter, keypresses are appended to the existing
+// contents of the register.
+func (m *Model) StartRecording(reg rune) {
+	if unicode.IsUpper(reg) {
+		reg = unicode.ToLower(reg)
+	} else {
+		m.registers[reg] = nil
+	}
+	m.recording = reg
+}
+
+// StopRecording stops recording keypresses.
+func (m *Model) StopRecording() {
+	m.recording = 0
+}
+
+// PlayMacro replays the keypresses stored in the given register count times.
+// All changes made during playback are grouped into a single undo step. The
+// register @ refers to the last played macro.
+func (m Model) PlayMacro(reg rune, count int) (Model, tea.Cmd) {
+	if reg == '@' {
+		if m.lastMacro == 0 {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "No previously used register", Error: true})
+		}
+		reg = m.lastMacro
+	}
+	reg = unicode.ToLower(reg)
+
+	keys := m.registers[reg]
+	if len(keys) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Register %c is empty", reg), Error: true})
+	}
+	if m.macroDepth >= maxMacroDepth {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Macro recursion too deep", Error: true})
+	}
+
+	m.lastMacro = reg
+	m.macroDepth++
+	undoStart := len(m.eb.UndoStack)
+
+	cmds := make([]tea.Cmd, 0, len(keys)*count)
+	for i := 0; i < count; i++ {
+		for _, key := range keys {
+			var cmd tea.Cmd
+			m, cmd = m.feedKey(key)
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	m.macroDepth--
+	m.eb.GroupChanges(undoStart)
+
+	return m, tea.Batch(cmds...)
+}
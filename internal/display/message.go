@@ -13,6 +13,16 @@ type BufferSavedMsg struct {
 	Quit         bool
 }
 
+type DumpEditedMsg struct {
+	Path string
+	Err  error
+}
+
+type RPCCallMsg struct {
+	call   rpcCall
+	server *rpcServer
+}
+
 func TeaMsgCmd(msg tea.Msg) tea.Cmd {
 	return func() tea.Msg {
 		return msg
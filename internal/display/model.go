@@ -26,6 +26,7 @@ const (
 
 	ActiveColumnHex ActiveColumn = iota
 	ActiveColumnAscii
+	ActiveColumnInspector
 )
 
 var (
@@ -47,9 +48,54 @@ type Model struct {
 
 	ResponsiveCols bool
 
+	// OnStatus is called with every status message if set. It is used to
+	// report messages when running without a terminal.
+	OnStatus func(text string, isError bool)
+	// Set once a command has asked the editor to quit
+	quitting bool
+
+	// Tint bytes by their class
+	colorBytes bool
+	// Encoding of the text column
+	charset *util.Charset
+	// Number of bytes displayed in each cell of the hex column
+	group int
+	// Byte order of groups in the hex column
+	groupByteOrder binary.ByteOrder
+	// Number format of cells in the hex column
+	cellFormat util.CellFormat
+	// Address of the first byte, added to offsets in the address column
+	addrBase uint64
+	// Display addresses in decimal instead of hex
+	addrDecimal bool
+
 	// Inspector
 	inspectorEnabled   bool
 	inspectorByteOrder binary.ByteOrder
+	// User-defined inspector rows
+	customRows []*util.CustomRow
+	// Bit field shown in the inspector, or a width of 0 to hide it
+	bitFieldOffset int
+	bitFieldWidth  int
+	// Selected row of the inspector when it is the active column
+	inspectorRow int
+	// Hide the inspector and plugin panels, such as when dumping
+	hidePanels bool
+
+	// Options applied when loading a file, keyed by file type
+	ftOptions map[string][]string
+
+	// Buffers that the current buffer was unpacked from, innermost last
+	parents []parentBuffer
+
+	// Checksum rules of the file being edited
+	checksums []core.ChecksumRule
+	// Store the correct checksums before saving
+	fixChecksumsOnSave bool
+	// Open Intel HEX and S-record files as the memory they describe
+	openRecordFiles bool
+	// Number of failing checksum rules, shown in the status bar
+	checksumCache *checksumCache
 
 	// Status bar error state
 	statusError bool
@@ -60,6 +106,39 @@ type Model struct {
 	// Command history
 	cmdHistory      []string
 	cmdHistoryIndex int
+	// Handler of the current prompt, or nil if the command line holds a
+	// command
+	promptHandler promptHandler
+
+	// Count prefix typed before a command in normal mode
+	count int
+	// Key waiting for a register name, such as q or @
+	pendingKey string
+
+	// Macro registers
+	registers map[rune][]tea.KeyMsg
+	// Register currently being recorded into, or 0 if not recording
+	recording rune
+	// Register of the last played macro
+	lastMacro rune
+	// Nesting depth of the macro currently being played
+	macroDepth int
+
+	// Key mappings for each mode
+	keymaps map[EditingMode][]keyMapping
+	// Keys typed so far that could start a mapping
+	pendingKeys []tea.KeyMsg
+	// Nesting depth of the mapping currently being expanded
+	mapDepth int
+	// Mappings expanded for the current keypress, or more than
+	// maxMapExpansions once expanding has been given up
+	mapExpansions int
+
+	// Lua engine used by the lua, run and source commands, created on first
+	// use
+	lua *luaEngine
+	// JSON-RPC server started by the rpc command, or nil if not running
+	rpc *rpcServer
 }
 
 func NewModel() Model {
@@ -68,21 +147,28 @@ func NewModel() Model {
 		width:        0,
 		height:       0,
 		nrows:        0,
-		ncols:        16,
 		viewRow:      0,
 		mode:         ModeNormal,
 		activeColumn: ActiveColumnHex,
 
 		ResponsiveCols: false,
 
-		inspectorEnabled:   true,
-		inspectorByteOrder: binary.LittleEndian,
+		ftOptions:     make(map[string][]string),
+		checksumCache: &checksumCache{},
 
 		statusError:     false,
 		cmdText:         textinput.New(),
 		tmpText:         textinput.New(),
 		cmdHistory:      []string{},
 		cmdHistoryIndex: 0,
+
+		registers: make(map[rune][]tea.KeyMsg),
+		keymaps:   make(map[EditingMode][]keyMapping),
+	}
+	for _, opt := range options {
+		if err := opt.Set(&m, opt.Default); err != nil {
+			panic(err)
+		}
 	}
 	m.SetMode(ModeNormal)
 	return m
@@ -100,39 +186,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.cmdText.Width = msg.Width
-
-		cols, rows := CalculateViewSize(m.width, m.height)
-		m.nrows = rows
-		if m.ResponsiveCols {
-			m.ncols = cols
-		}
-		m.ScrollToCursor()
+		m.updateViewSize()
 
 	// Handle keypresses
 	case tea.KeyMsg:
+		wasRecording := m.recording != 0
+		m, cmd := m.feedKey(msg)
 
-		switch m.mode {
-		case ModeNormal:
-			return HandleKeypressNormal(m, msg)
-
-		case ModeInsert:
-			return HandleKeypressInsert(m, msg)
-
-		case ModeVisual:
-			return HandleKeypressVisual(m, msg)
-
-		case ModeReplace:
-			return HandleKeypressInsert(m, msg)
-
-		case ModeCommand:
-			return HandleKeypressCommand(m, msg)
+		// Record the keypress if a macro is being recorded. The keys that
+		// start and stop the recording are not part of the macro.
+		if wasRecording && m.recording != 0 {
+			m.registers[m.recording] = append(m.registers[m.recording], msg)
 		}
 
+		return m, cmd
+
 	case StatusTextMsg:
 		if m.mode != ModeCommand {
 			m.StatusMessage(msg.Text, msg.Error)
 		}
 
+	case DumpEditedMsg:
+		defer os.Remove(msg.Path)
+		if msg.Err != nil {
+			m.StatusMessage(fmt.Sprintf("Error running editor: %s", msg.Err), true)
+			break
+		}
+
+		if text, err := m.applyDump(msg.Path); err != nil {
+			m.StatusMessage(fmt.Sprintf("Error applying dump: %s", err), true)
+		} else {
+			m.StatusMessage(text, false)
+		}
+
+	case RPCCallMsg:
+		return m.handleRPCCall(msg)
+
 	case BufferSavedMsg:
 		if msg.Quit {
 			return m, tea.Quit
@@ -141,6 +230,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if err := m.eb.Reload(); err != nil {
 			m.StatusMessage(fmt.Sprintf("Error reloading buffer: %s", err), true)
 		} else {
+			if f, ok := m.eb.Format.(*recordFile); ok {
+				m.addrBase = f.base
+			}
 			m.StatusMessage(fmt.Sprintf("Saved %d bytes to %s", msg.BytesWritten, msg.FileName), false)
 		}
 	}
@@ -148,6 +240,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleKey passes the keypress to the handler for the current mode.
+func (m Model) handleKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch m.mode {
+	case ModeNormal:
+		return HandleKeypressNormal(m, msg)
+
+	case ModeInsert:
+		return HandleKeypressInsert(m, msg)
+
+	case ModeVisual:
+		return HandleKeypressVisual(m, msg)
+
+	case ModeReplace:
+		return HandleKeypressInsert(m, msg)
+
+	case ModeCommand:
+		return HandleKeypressCommand(m, msg)
+	}
+
+	return m, nil
+}
+
 func (m Model) View() string {
 	tStart := time.Now()
 
@@ -168,15 +282,60 @@ func (m Model) View() string {
 	return fmt.Sprintf("%s\n%s", hexView, statusBar)
 }
 
+// LoadFile loads a file into the buffer. Intel HEX and S-record files are
+// loaded as the memory they describe, unless they cannot be read as such.
 func (m *Model) LoadFile(name string) error {
+	if format := m.recordFormat(name); format != nil {
+		err := m.loadRecordFile(name, format)
+		if err == nil {
+			return nil
+		}
+		defer m.StatusMessage(fmt.Sprintf("Opened as raw bytes, %s", err), true)
+	}
+
 	f, err := os.Open(name)
 	if err != nil {
 		return fmt.Errorf("failed to open file %s: %w", name, err)
 	}
-	m.eb = core.NewEditorBuffer(name, f)
+	m.LoadReader(name, f)
 	return nil
 }
 
+// LoadReader loads the buffer from a reader. The name is used to save the
+// buffer and to detect its file type.
+func (m *Model) LoadReader(name string, r io.ReadSeeker) {
+	m.eb = core.NewEditorBuffer(name, r)
+	if err := m.applyFileTypeOptions(); err != nil {
+		m.StatusMessage(err.Error(), true)
+	}
+	if err := m.loadChecksumRules(); err != nil {
+		m.StatusMessage(err.Error(), true)
+	}
+}
+
+// Close releases the resources held by the model, such as the socket of the
+// JSON-RPC server.
+func (m Model) Close() {
+	if m.rpc != nil {
+		m.rpc.Close()
+	}
+}
+
+// updateViewSize recalculates the number of rows and columns after the
+// terminal size or the layout has changed.
+func (m *Model) updateViewSize() {
+	if m.width == 0 || m.height == 0 {
+		return
+	}
+
+	cols, rows := m.CalculateViewSize(m.width, m.height)
+	m.nrows = rows
+	if m.ResponsiveCols {
+		m.ncols = cols
+	}
+	m.ScrollToCursor()
+}
+
 // SetCursor sets the cursor position.
 func (m *Model) SetCursor(pos int64) {
 	m.eb.Cursor = util.Clamp(pos, 0, m.eb.Size()-1)
@@ -205,6 +364,37 @@ func (m *Model) ScrollToCursor() {
 	}
 }
 
+// readBytes reads n bytes at the offset of the buffer.
+func (m *Model) readBytes(offset, n int64) ([]byte, error) {
+	buf := make([]byte, n)
+	r := m.eb.ReadSeeker()
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// commitChange commits a change to the buffer. It returns an error if the
+// change starts or removes bytes past the end of the buffer, or if bytes typed
+// in insert or replace mode have not been committed yet, as the change would
+// replace them.
+func (m *Model) commitChange(chg core.Change) error {
+	if m.eb.Preview != nil {
+		return fmt.Errorf("cannot change the buffer while typing in insert or replace mode")
+	}
+	size := m.eb.Size()
+	if chg.Position < 0 || chg.Removed < 0 || chg.Position+chg.Removed > size {
+		return fmt.Errorf("range %d..%d is outside the buffer (size %d)", chg.Position, chg.Position+chg.Removed, size)
+	}
+
+	m.eb.PreviewChange(&chg)
+	m.eb.CommitChange()
+	return nil
+}
+
 // SetMode sets the editing mode.
 func (m *Model) SetMode(mode EditingMode) {
 	m.prevMode = m.mode
@@ -213,6 +403,7 @@ func (m *Model) SetMode(mode EditingMode) {
 	m.statusError = false
 	m.cmdText.SetValue("")
 	m.tmpText.SetValue("")
+	m.promptHandler = nil
 	if mode == ModeCommand {
 		m.cmdText.Prompt = ":"
 		m.cmdText.Focus()
@@ -233,4 +424,13 @@ func (m *Model) SetMode(mode EditingMode) {
 func (m *Model) StatusMessage(msg string, isError bool) {
 	m.cmdText.SetValue(msg)
 	m.statusError = isError
+	if m.OnStatus != nil {
+		m.OnStatus(msg, isError)
+	}
+}
+
+// Quitting returns true if a command executed with ExecCommand has asked the
+// editor to quit.
+func (m Model) Quitting() bool {
+	return m.quitting
 }
@@ -0,0 +1,21 @@
+package display
+
+import (
+	"bytes"
+	"io"
+)
+
+// newTestModel returns a model editing a buffer with the given contents.
+func newTestModel(data string) Model {
+	m := NewModel()
+	m.LoadReader("test.bin", bytes.NewReader([]byte(data)))
+	return m
+}
+
+// testContents returns the contents of the buffer.
+func testContents(m Model) string {
+	r := m.eb.ReadSeeker()
+	r.Seek(0, io.SeekStart)
+	data, _ := io.ReadAll(r)
+	return string(data)
+}
@@ -0,0 +1,456 @@
+package display
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+type OptionType int
+
+const (
+	OptionBool OptionType = iota
+	OptionInt
+	OptionString
+)
+
+// Option describes a runtime option that can be changed with the set command.
+type Option struct {
+	// Name is the canonical name of the option.
+	Name string
+
+	// Aliases are alternative names that refer to the same option.
+	Aliases []string
+
+	// Type is the type of the option's value.
+	Type OptionType
+
+	// Default is the default value of the option, as accepted by Set.
+	Default string
+
+	// Get returns the current value of the option.
+	Get func(m *Model) string
+
+	// Set parses the value and applies it to the model. Values of boolean
+	// options are normalized to "true" or "false" before Set is called.
+	Set func(m *Model, value string) error
+}
+
+// options is the registry of all known options.
+var options = []*Option{
+	intOption("cols", nil, 16, func(m *Model, v int) error {
+		if v <= 0 {
+			return fmt.Errorf("cols must be positive")
+		}
+		if m.group > 0 && v%m.group != 0 {
+			return fmt.Errorf("cols must be a multiple of the group size")
+		}
+		m.ncols = v
+		m.ScrollToCursor()
+		return nil
+	}, func(m *Model) int { return m.ncols }),
+
+	boolOption("inspector", []string{"inspector.enabled"}, true,
+		func(m *Model) *bool { return &m.inspectorEnabled }),
+
+	{
+		Name:    "byteorder",
+		Aliases: []string{"inspector.byteOrder"},
+		Type:    OptionString,
+		Default: "little",
+		Get: func(m *Model) string {
+			if m.inspectorByteOrder == binary.BigEndian {
+				return "big"
+			}
+			return "little"
+		},
+		Set: func(m *Model, value string) error {
+			order, err := util.ParseByteOrder(value)
+			if err != nil {
+				return err
+			}
+			m.inspectorByteOrder = order
+			return nil
+		},
+	},
+
+	{
+		Name:    "bitfield",
+		Type:    OptionString,
+		Default: "off",
+		Get: func(m *Model) string {
+			if m.bitFieldWidth == 0 {
+				return "off"
+			}
+			return fmt.Sprintf("%d:%d", m.bitFieldOffset, m.bitFieldWidth)
+		},
+		Set: func(m *Model, value string) error {
+			if value == "off" || value == "" {
+				m.bitFieldWidth = 0
+				return nil
+			}
+			offset, width, ok := strings.Cut(value, ":")
+			o, err1 := strconv.Atoi(offset)
+			w, err2 := strconv.Atoi(width)
+			if !ok || err1 != nil || err2 != nil || o < 0 || w < 1 || o+w > 64 {
+				return fmt.Errorf("expected <offset>:<width> within 64 bits, or off")
+			}
+			m.bitFieldOffset, m.bitFieldWidth = o, w
+			return nil
+		},
+	},
+
+	intOption("group", nil, 1, func(m *Model, v int) error {
+		if err := util.ValidateCellFormat(m.cellFormat, v); err != nil {
+			return err
+		}
+		if m.ncols%v != 0 {
+			return fmt.Errorf("cols must be a multiple of the group size")
+		}
+		m.group = v
+		m.updateViewSize()
+		return nil
+	}, func(m *Model) int { return m.group }),
+
+	{
+		Name:    "endian",
+		Type:    OptionString,
+		Default: "big",
+		Get: func(m *Model) string {
+			if m.groupByteOrder == binary.LittleEndian {
+				return "little"
+			}
+			return "big"
+		},
+		Set: func(m *Model, value string) error {
+			order, err := util.ParseByteOrder(value)
+			if err != nil {
+				return err
+			}
+			m.groupByteOrder = order
+			return nil
+		},
+	},
+
+	{
+		Name:    "format",
+		Type:    OptionString,
+		Default: "hex",
+		Get:     func(m *Model) string { return m.cellFormat.String() },
+		Set: func(m *Model, value string) error {
+			format, err := util.ParseCellFormat(value)
+			if err != nil {
+				return err
+			}
+			if err := util.ValidateCellFormat(format, m.group); err != nil {
+				return err
+			}
+			m.cellFormat = format
+			m.updateViewSize()
+			return nil
+		},
+	},
+
+	boolOption("colorbytes", nil, false,
+		func(m *Model) *bool { return &m.colorBytes }),
+
+	boolOption("fixchecksums", nil, true,
+		func(m *Model) *bool { return &m.fixChecksumsOnSave }),
+
+	boolOption("hexfiles", nil, true,
+		func(m *Model) *bool { return &m.openRecordFiles }),
+
+	{
+		Name:    "encoding",
+		Aliases: []string{"enc"},
+		Type:    OptionString,
+		Default: "ascii",
+		Get:     func(m *Model) string { return m.charset.Name },
+		Set: func(m *Model, value string) error {
+			charset, err := util.LookupCharset(value)
+			if err != nil {
+				return err
+			}
+			m.charset = charset
+			return nil
+		},
+	},
+
+	{
+		Name:    "addrbase",
+		Type:    OptionInt,
+		Default: "0",
+		Get:     func(m *Model) string { return fmt.Sprintf("0x%x", m.addrBase) },
+		Set: func(m *Model, value string) error {
+			v, err := util.ParseNumber(value, 10)
+			if err != nil {
+				return err
+			}
+			m.addrBase = v
+			m.updateViewSize()
+			return nil
+		},
+	},
+
+	{
+		Name:    "addrformat",
+		Type:    OptionString,
+		Default: "hex",
+		Get: func(m *Model) string {
+			if m.addrDecimal {
+				return "dec"
+			}
+			return "hex"
+		},
+		Set: func(m *Model, value string) error {
+			switch value {
+			case "hex":
+				m.addrDecimal = false
+			case "dec":
+				m.addrDecimal = true
+			default:
+				return fmt.Errorf("expected either hex or dec")
+			}
+			m.updateViewSize()
+			return nil
+		},
+	},
+
+	{
+		Name:    "colors",
+		Type:    OptionString,
+		Default: "auto",
+		Get:     func(m *Model) string { return currentColorMode },
+		Set:     func(m *Model, value string) error { return setColorMode(value) },
+	},
+}
+
+// boolOption creates a boolean option backed by a field of the model.
+func boolOption(name string, aliases []string, def bool, field func(m *Model) *bool) *Option {
+	return &Option{
+		Name:    name,
+		Aliases: aliases,
+		Type:    OptionBool,
+		Default: strconv.FormatBool(def),
+		Get:     func(m *Model) string { return strconv.FormatBool(*field(m)) },
+		Set: func(m *Model, value string) error {
+			*field(m) = value == "true"
+			return nil
+		},
+	}
+}
+
+// intOption creates an integer option with the given setter and getter.
+func intOption(name string, aliases []string, def int, set func(m *Model, v int) error, get func(m *Model) int) *Option {
+	return &Option{
+		Name:    name,
+		Aliases: aliases,
+		Type:    OptionInt,
+		Default: strconv.Itoa(def),
+		Get:     func(m *Model) string { return strconv.Itoa(get(m)) },
+		Set: func(m *Model, value string) error {
+			v, err := strconv.ParseInt(value, 0, 0)
+			if err != nil {
+				return fmt.Errorf("expected a number")
+			}
+			return set(m, int(v))
+		},
+	}
+}
+
+// parseBool parses a boolean option value.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("expected either true or false")
+	}
+	return b, nil
+}
+
+// LookupOption returns the option with the given name or alias, or nil if
+// there is no such option.
+func LookupOption(name string) *Option {
+	for _, opt := range options {
+		if opt.Name == name {
+			return opt
+		}
+		for _, alias := range opt.Aliases {
+			if alias == name {
+				return opt
+			}
+		}
+	}
+	return nil
+}
+
+// SetOption sets the value of the option with the given name.
+func (m *Model) SetOption(name, value string) error {
+	opt := LookupOption(name)
+	if opt == nil {
+		return fmt.Errorf("unknown option: %s", name)
+	}
+
+	if opt.Type == OptionBool {
+		b, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", opt.Name, err)
+		}
+		value = strconv.FormatBool(b)
+	}
+
+	if err := opt.Set(m, value); err != nil {
+		return fmt.Errorf("%s: %w", opt.Name, err)
+	}
+	return nil
+}
+
+// formatOption formats the option's current value the way set displays it.
+func (m *Model) formatOption(opt *Option) string {
+	value := opt.Get(m)
+	if opt.Type == OptionBool {
+		if value == "true" {
+			return opt.Name
+		}
+		return "no" + opt.Name
+	}
+	return opt.Name + "=" + value
+}
+
+// applySetArgs applies the arguments of a set command. Each argument can be
+// one of the following:
+//
+//	option          Set a boolean option, or show the value of other options
+//	nooption        Unset a boolean option
+//	invoption       Toggle a boolean option
+//	option!         Toggle a boolean option
+//	option?         Show the value of the option
+//	option&         Reset the option to its default value
+//	option=value    Set the option to the value
+//
+// The returned string contains the values that were requested to be shown.
+func (m *Model) applySetArgs(args []string) (string, error) {
+	// Support the "set <option> <value>" form
+	if len(args) == 2 && !strings.ContainsAny(args[0]+args[1], "=?&!") &&
+		LookupOption(args[0]) != nil && LookupOption(args[1]) == nil {
+		return "", m.SetOption(args[0], args[1])
+	}
+
+	shown := make([]string, 0)
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+
+		if arg == "all" {
+			for _, opt := range options {
+				shown = append(shown, m.formatOption(opt))
+			}
+			continue
+		}
+
+		if name, value, ok := strings.Cut(arg, "="); ok {
+			if err := m.SetOption(name, value); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		name := arg[:len(arg)-1]
+		switch arg[len(arg)-1] {
+		case '?':
+			opt := LookupOption(name)
+			if opt == nil {
+				return "", fmt.Errorf("unknown option: %s", name)
+			}
+			shown = append(shown, m.formatOption(opt))
+			continue
+
+		case '&':
+			opt := LookupOption(name)
+			if opt == nil {
+				return "", fmt.Errorf("unknown option: %s", name)
+			}
+			if err := opt.Set(m, opt.Default); err != nil {
+				return "", err
+			}
+			continue
+
+		case '!':
+			if err := m.toggleOption(name); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if opt := LookupOption(arg); opt != nil {
+			if opt.Type == OptionBool {
+				if err := opt.Set(m, "true"); err != nil {
+					return "", err
+				}
+			} else {
+				shown = append(shown, m.formatOption(opt))
+			}
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(arg, "inv"); ok && LookupOption(name) != nil {
+			if err := m.toggleOption(name); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(arg, "no"); ok && LookupOption(name) != nil {
+			if LookupOption(name).Type != OptionBool {
+				return "", fmt.Errorf("invalid argument: %s", arg)
+			}
+			if err := m.SetOption(name, "false"); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		return "", fmt.Errorf("unknown option: %s", arg)
+	}
+
+	return strings.Join(shown, "  "), nil
+}
+
+// toggleOption inverts the value of a boolean option.
+func (m *Model) toggleOption(name string) error {
+	opt := LookupOption(name)
+	if opt == nil {
+		return fmt.Errorf("unknown option: %s", name)
+	}
+	if opt.Type != OptionBool {
+		return fmt.Errorf("invalid argument: %s!", name)
+	}
+	return opt.Set(m, strconv.FormatBool(opt.Get(m) != "true"))
+}
+
+// fileType returns the file type of the named file, which is its lowercase
+// extension without the leading dot.
+func fileType(name string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// applyFileTypeOptions applies the options registered with ftset for the
+// file type of the current buffer.
+func (m *Model) applyFileTypeOptions() error {
+	args, ok := m.ftOptions[fileType(m.eb.Name)]
+	if !ok {
+		return nil
+	}
+	_, err := m.applySetArgs(args)
+	return err
+}
@@ -0,0 +1,21 @@
+package display
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// promptHandler handles the text entered into a prompt.
+type promptHandler func(m Model, input string) (Model, tea.Cmd)
+
+// Prompt asks the user for a line of text in the command line. The handler is
+// called with the text when the user presses enter. Pressing escape cancels
+// the prompt without calling the handler. A prompt opened by a command
+// returns to the mode the command was typed in.
+func (m *Model) Prompt(label string, handler promptHandler) {
+	prevMode := m.prevMode
+	wasCommand := m.mode == ModeCommand
+	m.SetMode(ModeCommand)
+	if wasCommand {
+		m.prevMode = prevMode
+	}
+	m.cmdText.Prompt = label
+	m.promptHandler = handler
+}
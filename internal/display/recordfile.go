@@ -0,0 +1,95 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/hexfile"
+)
+
+// recordFile is the format of a buffer opened from an Intel HEX or S-record
+// file. The buffer holds the memory described by the records, starting at the
+// lowest address, with holes where there are no records.
+type recordFile struct {
+	format *hexfile.Format
+	layout *hexfile.Layout
+
+	// Address of the first byte of the buffer
+	base uint64
+}
+
+var _ core.FileFormat = &recordFile{}
+
+// Open reads the records of the file. The base address is taken from the
+// first record when the file is first opened, and kept afterwards unless the
+// file has data below it.
+func (f *recordFile) Open(name string) (io.ReadSeeker, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	segments, layout, err := f.format.ReadLayout(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", f.format.Name, err)
+	}
+	if len(segments) > 0 && (f.layout == nil || segments[0].Address < f.base) {
+		f.base = segments[0].Address
+	}
+	f.layout = layout
+
+	extents := make([]core.Extent, len(segments))
+	for i, s := range segments {
+		extents[i] = core.Extent{Offset: int64(s.Address - f.base), Data: s.Data}
+	}
+	return core.NewSparseReader(extents, erasedByte)
+}
+
+// Write writes everything but the holes of the buffer as records, laid out
+// like the records that were read as far as possible.
+func (f *recordFile) Write(w io.Writer, r io.ReadSeeker, size int64, holes []core.Range) error {
+	extents := core.Extents(size, holes)
+	segments := make([]hexfile.Segment, len(extents))
+	for i, e := range extents {
+		data := make([]byte, e.End-e.Start+1)
+		if _, err := r.Seek(e.Start, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, data); err != nil {
+			return err
+		}
+		segments[i] = hexfile.Segment{Address: f.base + uint64(e.Start), Data: data}
+	}
+	return f.format.WriteLayout(w, segments, f.layout)
+}
+
+// recordFormat returns the format of the file if it should be opened as
+// records, or nil.
+func (m *Model) recordFormat(name string) *hexfile.Format {
+	if !m.openRecordFiles {
+		return nil
+	}
+	if f := hexfile.FormatForFile(name); f != nil && f.ReadLayout != nil {
+		return f
+	}
+	return nil
+}
+
+// loadRecordFile loads an Intel HEX or S-record file into a sparse buffer,
+// and shows the addresses of the records in the address column.
+func (m *Model) loadRecordFile(name string, format *hexfile.Format) error {
+	f := &recordFile{format: format}
+	r, err := f.Open(name)
+	if err != nil {
+		return err
+	}
+
+	m.LoadReader(name, r)
+	m.eb.Format = f
+	m.addrBase = f.base
+	m.updateViewSize()
+	return nil
+}
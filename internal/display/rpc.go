@@ -0,0 +1,459 @@
+package display
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     json.RawMessage
+	Result any
+	Error  *rpcError
+}
+
+// MarshalJSON encodes the response with either a result, which can be null,
+// or an error.
+func (r rpcResponse) MarshalJSON() ([]byte, error) {
+	id := r.ID
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	out := map[string]any{"jsonrpc": "2.0", "id": id}
+	if r.Error != nil {
+		out["error"] = r.Error
+	} else {
+		out["result"] = r.Result
+	}
+	return json.Marshal(out)
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// rpcCall is a request waiting to be handled by the model. The response is
+// sent back on reply.
+type rpcCall struct {
+	req   rpcRequest
+	reply chan rpcResponse
+}
+
+// rpcServer accepts JSON-RPC connections on a Unix socket. Requests are
+// handed to the model through calls, so that they are handled in the
+// bubbletea event loop like keypresses.
+type rpcServer struct {
+	path     string
+	listener net.Listener
+	calls    chan rpcCall
+	done     chan struct{}
+	closed   sync.Once
+
+	// Private directory holding the socket, removed along with it
+	dir string
+}
+
+// listenRPC starts a server on the Unix socket at path. A stale socket left
+// behind by a previous run is removed. Without a path, the socket is created
+// in a new directory that only the user can access. Either way, the socket
+// itself is only accessible to the user, as clients can run any command.
+func listenRPC(path string) (*rpcServer, error) {
+	dir := ""
+	if path == "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "gex-"); err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, fmt.Sprintf("gex-%d.sock", os.Getpid()))
+	} else if st, err := os.Stat(path); err == nil && st.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use", path)
+		}
+		os.Remove(path)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err == nil {
+		err = os.Chmod(path, 0600)
+	}
+	if err != nil {
+		if l != nil {
+			l.Close()
+		}
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+		return nil, err
+	}
+
+	s := &rpcServer{
+		path:     path,
+		listener: l,
+		calls:    make(chan rpcCall),
+		done:     make(chan struct{}),
+		dir:      dir,
+	}
+	go s.accept()
+	return s, nil
+}
+
+// Close stops the server and removes the socket.
+func (s *rpcServer) Close() {
+	s.closed.Do(func() {
+		close(s.done)
+		s.listener.Close()
+		os.Remove(s.path)
+		if s.dir != "" {
+			os.Remove(s.dir)
+		}
+	})
+}
+
+func (s *rpcServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve reads requests from a connection, one JSON object per line, and
+// writes a response line for each request that has an ID.
+func (s *rpcServer) serve(conn net.Conn) {
+	defer conn.Close()
+	go func() {
+		<-s.done
+		conn.Close()
+	}()
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	enc := json.NewEncoder(conn)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+
+		var req rpcRequest
+		var resp rpcResponse
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			resp = rpcResponse{Error: &rpcError{rpcParseError, err.Error()}}
+		} else if req.JSONRPC != "2.0" || req.Method == "" {
+			resp = rpcResponse{ID: req.ID, Error: &rpcError{rpcInvalidRequest, "invalid request"}}
+		} else {
+			call := rpcCall{req: req, reply: make(chan rpcResponse, 1)}
+			select {
+			case s.calls <- call:
+			case <-s.done:
+				return
+			}
+			select {
+			case resp = <-call.reply:
+			case <-s.done:
+				return
+			}
+			if len(req.ID) == 0 {
+				// Notifications do not get a response
+				continue
+			}
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// waitForRPC returns a command that waits for the next request to the server.
+func waitForRPC(s *rpcServer) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case call := <-s.calls:
+			return RPCCallMsg{call: call, server: s}
+		case <-s.done:
+			return nil
+		}
+	}
+}
+
+// handleRPCCommand starts, stops or shows the JSON-RPC server.
+func handleRPCCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "listen", "start":
+		if m.rpc != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Already listening on " + m.rpc.path, Error: true})
+		}
+
+		s, err := listenRPC(strings.Join(args[1:], " "))
+		if err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "Error starting RPC server: " + err.Error(), Error: true})
+		}
+		m.rpc = s
+		return m, tea.Batch(TeaMsgCmd(StatusTextMsg{Text: "Listening on " + s.path}), waitForRPC(s))
+
+	case "stop":
+		if m.rpc == nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "RPC server is not running", Error: true})
+		}
+		m.rpc.Close()
+		m.rpc = nil
+		return m, TeaMsgCmd(StatusTextMsg{Text: "RPC server stopped"})
+
+	case "status":
+		if m.rpc == nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: "RPC server is not running"})
+		}
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Listening on " + m.rpc.path})
+	}
+
+	return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: rpc [listen [path]|stop]", Error: true})
+}
+
+// handleRPCCall handles a request and sends the response back to the
+// connection it came from.
+func (m Model) handleRPCCall(msg RPCCallMsg) (Model, tea.Cmd) {
+	resp := rpcResponse{ID: msg.call.req.ID}
+	method, ok := rpcMethods[msg.call.req.Method]
+	if !ok {
+		resp.Error = &rpcError{rpcMethodNotFound, "method not found: " + msg.call.req.Method}
+	} else if result, err := method(&m, msg.call.req.Params); err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{rpcServerError, err.Error()}
+		}
+		resp.Error = rerr
+	} else {
+		resp.Result = result
+	}
+	msg.call.reply <- resp
+
+	// Keep waiting for requests unless the server has been replaced, or a
+	// command has asked the editor to quit
+	if m.quitting {
+		return m, tea.Quit
+	}
+	if m.rpc != msg.server {
+		return m, nil
+	}
+	return m, waitForRPC(msg.server)
+}
+
+// rpcParams decodes the parameters of a request into v.
+func rpcParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{rpcInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+// rpcData is a range of the buffer and its bytes, which are sent as hex.
+type rpcData struct {
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	Data   string `json:"data"`
+}
+
+// bytes decodes the hex data.
+func (d rpcData) bytes() ([]byte, error) {
+	b, err := hex.DecodeString(d.Data)
+	if err != nil {
+		return nil, &rpcError{rpcInvalidParams, "data must be hex: " + err.Error()}
+	}
+	return b, nil
+}
+
+// rpcRegion is a region of the buffer. The end is inclusive.
+type rpcRegion struct {
+	Type  string `json:"type"`
+	Start int64  `json:"start"`
+	End   int64  `json:"end"`
+}
+
+// regionTypeName returns the name of a region type.
+func regionTypeName(t core.RegionType) string {
+	for name, rt := range regionTypeNames {
+		if rt == t {
+			return name
+		}
+	}
+	return "none"
+}
+
+// rpcMethod handles a JSON-RPC method. The result is encoded as JSON.
+type rpcMethod func(m *Model, params json.RawMessage) (any, error)
+
+// rpcMethods are the methods served over JSON-RPC. They mirror the Lua
+// module. They are set in init because the command method refers back to the
+// model's Update.
+var rpcMethods map[string]rpcMethod
+
+func init() {
+	rpcMethods = map[string]rpcMethod{
+		"size": func(m *Model, params json.RawMessage) (any, error) {
+			return m.eb.Size(), nil
+		},
+
+		"read": func(m *Model, params json.RawMessage) (any, error) {
+			p := rpcData{Length: 1}
+			if err := rpcParams(params, &p); err != nil {
+				return nil, err
+			}
+			if p.Offset < 0 || p.Length < 0 {
+				return nil, &rpcError{rpcInvalidParams, "offset and length must not be negative"}
+			}
+
+			buf := make([]byte, util.Min(p.Length, util.Max(m.eb.Size()-p.Offset, 0)))
+			r := m.eb.ReadSeeker()
+			if _, err := r.Seek(p.Offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			n, err := io.ReadFull(r, buf)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return nil, err
+			}
+			return rpcData{Offset: p.Offset, Length: int64(n), Data: hex.EncodeToString(buf[:n])}, nil
+		},
+
+		"write": func(m *Model, params json.RawMessage) (any, error) {
+			var p rpcData
+			if err := rpcParams(params, &p); err != nil {
+				return nil, err
+			}
+			data, err := p.bytes()
+			if err != nil {
+				return nil, err
+			}
+			return nil, m.commitChange(core.Change{
+				Position: p.Offset,
+				Removed:  util.Clamp(m.eb.Size()-p.Offset, 0, int64(len(data))),
+				Data:     data,
+			})
+		},
+
+		"insert": func(m *Model, params json.RawMessage) (any, error) {
+			var p rpcData
+			if err := rpcParams(params, &p); err != nil {
+				return nil, err
+			}
+			data, err := p.bytes()
+			if err != nil {
+				return nil, err
+			}
+			return nil, m.commitChange(core.Change{Position: p.Offset, Data: data})
+		},
+
+		"delete": func(m *Model, params json.RawMessage) (any, error) {
+			var p rpcData
+			if err := rpcParams(params, &p); err != nil {
+				return nil, err
+			}
+			return nil, m.commitChange(core.Change{Position: p.Offset, Removed: p.Length})
+		},
+
+		"cursor": func(m *Model, params json.RawMessage) (any, error) {
+			return m.eb.Cursor, nil
+		},
+
+		"set_cursor": func(m *Model, params json.RawMessage) (any, error) {
+			var p rpcData
+			if err := rpcParams(params, &p); err != nil {
+				return nil, err
+			}
+			m.SetCursor(p.Offset)
+			if m.mode != ModeVisual {
+				m.eb.SelectionStart = m.eb.Cursor
+			}
+			return m.eb.Cursor, nil
+		},
+
+		"selection": func(m *Model, params json.RawMessage) (any, error) {
+			start, end := m.eb.GetSelectionRange()
+			return rpcRegion{Type: "selection", Start: start, End: end}, nil
+		},
+
+		"regions": func(m *Model, params json.RawMessage) (any, error) {
+			regions := make([]rpcRegion, len(m.eb.Regions))
+			for i, r := range m.eb.Regions {
+				regions[i] = rpcRegion{Type: regionTypeName(r.Type), Start: r.Start, End: r.End}
+			}
+			sort.SliceStable(regions, func(i, j int) bool { return regions[i].Start < regions[j].Start })
+			return regions, nil
+		},
+
+		"highlight": func(m *Model, params json.RawMessage) (any, error) {
+			var p rpcRegion
+			if err := rpcParams(params, &p); err != nil {
+				return nil, err
+			}
+			if p.Start < 0 || p.End < p.Start {
+				return nil, &rpcError{rpcInvalidParams, "end must not be before start"}
+			}
+			m.eb.Regions = append(m.eb.Regions, core.Region{
+				Type:  core.RegionTypeHighlight,
+				Range: core.Range{Start: p.Start, End: p.End},
+			})
+			return nil, nil
+		},
+
+		"clear_regions": func(m *Model, params json.RawMessage) (any, error) {
+			m.eb.Regions = nil
+			return nil, nil
+		},
+
+		"command": func(m *Model, params json.RawMessage) (any, error) {
+			var p struct {
+				Command string `json:"command"`
+			}
+			if err := rpcParams(params, &p); err != nil {
+				return nil, err
+			}
+			return nil, m.ExecCommand(p.Command)
+		},
+	}
+}
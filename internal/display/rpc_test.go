@@ -0,0 +1,157 @@
+package display
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// rpcTestCall handles a request as if it came from a connection, and returns
+// the response.
+func rpcTestCall(m Model, method, params string) (Model, rpcResponse) {
+	call := rpcCall{
+		req:   rpcRequest{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: method, Params: json.RawMessage(params)},
+		reply: make(chan rpcResponse, 1),
+	}
+	m, _ = m.handleRPCCall(RPCCallMsg{call: call})
+	return m, <-call.reply
+}
+
+func TestRPCCall(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		method    string
+		params    string
+		expResult any
+		expError  int
+		expData   string
+	}{
+		{"size", "", int64(6), 0, "abcdef"},
+		{"read", `{"offset": 1, "length": 3}`, rpcData{Offset: 1, Length: 3, Data: "626364"}, 0, "abcdef"},
+		{"read", `{"offset": 4, "length": 8}`, rpcData{Offset: 4, Length: 2, Data: "6566"}, 0, "abcdef"},
+		{"read", `{"offset": 2}`, rpcData{Offset: 2, Length: 1, Data: "63"}, 0, "abcdef"},
+		{"read", `{"offset": -1}`, nil, rpcInvalidParams, "abcdef"},
+		{"read", `{"offset": "x"}`, nil, rpcInvalidParams, "abcdef"},
+		{"write", `{"offset": 1, "data": "5859"}`, nil, 0, "aXYdef"},
+		{"write", `{"offset": 5, "data": "5859"}`, nil, 0, "abcdeXY"},
+		{"write", `{"offset": 1, "data": "zz"}`, nil, rpcInvalidParams, "abcdef"},
+		{"write", `{"offset": 7, "data": "58"}`, nil, rpcServerError, "abcdef"},
+		{"insert", `{"offset": 0, "data": "58"}`, nil, 0, "Xabcdef"},
+		{"delete", `{"offset": 2, "length": 2}`, nil, 0, "abef"},
+		{"delete", `{"offset": 4, "length": 4}`, nil, rpcServerError, "abcdef"},
+		{"set_cursor", `{"offset": 3}`, int64(3), 0, "abcdef"},
+		{"highlight", `{"start": 1, "end": 2}`, nil, 0, "abcdef"},
+		{"highlight", `{"start": 2, "end": 1}`, nil, rpcInvalidParams, "abcdef"},
+		{"command", `{"command": "fill 00"}`, nil, 0, "\x00bcdef"},
+		{"command", `{"command": "nosuchcommand"}`, nil, rpcServerError, "abcdef"},
+		{"nosuchmethod", "", nil, rpcMethodNotFound, "abcdef"},
+	}
+
+	for _, test := range matrix {
+		m, resp := rpcTestCall(newTestModel("abcdef"), test.method, test.params)
+		if test.expError != 0 {
+			if assert.NotNil(resp.Error, test.method+test.params) {
+				assert.Equal(test.expError, resp.Error.Code, test.method+test.params)
+			}
+		} else {
+			assert.Nil(resp.Error, test.method+test.params)
+			assert.Equal(test.expResult, resp.Result, test.method+test.params)
+		}
+		assert.Equal(json.RawMessage("1"), resp.ID, test.method+test.params)
+		assert.Equal(test.expData, testContents(m), test.method+test.params)
+	}
+}
+
+func TestRPCHighlight(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newTestModel("abcdef")
+	m, _ = rpcTestCall(m, "highlight", `{"start": 4, "end": 5}`)
+	m, _ = rpcTestCall(m, "highlight", `{"start": 0, "end": 1}`)
+	m, resp := rpcTestCall(m, "regions", "")
+	assert.Equal([]rpcRegion{
+		{Type: "highlight", Start: 0, End: 1},
+		{Type: "highlight", Start: 4, End: 5},
+	}, resp.Result)
+
+	m, _ = rpcTestCall(m, "clear_regions", "")
+	_, resp = rpcTestCall(m, "regions", "")
+	assert.Equal([]rpcRegion{}, resp.Result)
+}
+
+func TestRPCWhileTyping(t *testing.T) {
+	assert := assert.New(t)
+
+	key := func(m Model, k string) Model {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if k == "esc" {
+			msg = tea.KeyMsg{Type: tea.KeyEscape}
+		}
+		model, _ := m.Update(msg)
+		return model.(Model)
+	}
+
+	// Type 58 in insert mode at the start of the buffer, and try to change the
+	// buffer before leaving insert mode
+	m := newTestModel("abcdef")
+	for _, k := range []string{"i", "5", "8"} {
+		m = key(m, k)
+	}
+	for _, method := range []string{"write", "insert", "delete"} {
+		var resp rpcResponse
+		m, resp = rpcTestCall(m, method, `{"offset": 4, "length": 1, "data": "59"}`)
+		if assert.NotNil(resp.Error, method) {
+			assert.Equal(rpcServerError, resp.Error.Code, method)
+		}
+	}
+	m, resp := rpcTestCall(m, "read", `{"offset": 0, "length": 2}`)
+	assert.Equal(rpcData{Offset: 0, Length: 2, Data: "5861"}, resp.Result)
+
+	// The typed bytes stay where they were typed
+	for _, k := range []string{"5", "9", "esc"} {
+		m = key(m, k)
+	}
+	assert.Equal("XYabcdef", testContents(m))
+
+	// Changes are accepted again once the typed bytes are committed
+	m, resp = rpcTestCall(m, "write", `{"offset": 0, "data": "59"}`)
+	assert.Nil(resp.Error)
+	assert.Equal("YYabcdef", testContents(m))
+}
+
+func TestListenRPC(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "gex.sock")
+	s, err := listenRPC(path)
+	if !assert.NoError(err) {
+		return
+	}
+	st, err := os.Stat(path)
+	if assert.NoError(err) {
+		assert.Equal(os.FileMode(0600), st.Mode().Perm())
+	}
+	_, err = listenRPC(path)
+	assert.Error(err, "socket in use")
+	s.Close()
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err))
+
+	// Without a path, the socket is created in a private directory
+	s, err = listenRPC("")
+	if !assert.NoError(err) {
+		return
+	}
+	st, err = os.Stat(filepath.Dir(s.path))
+	if assert.NoError(err) {
+		assert.Equal(os.FileMode(0700), st.Mode().Perm())
+	}
+	s.Close()
+	_, err = os.Stat(filepath.Dir(s.path))
+	assert.True(os.IsNotExist(err))
+}
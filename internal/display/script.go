@@ -0,0 +1,267 @@
+package display
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// luaEngine runs Lua scripts against the editor. The Lua state is kept
+// between scripts, so globals and prompt callbacks outlive the script that
+// created them.
+type luaEngine struct {
+	L *lua.LState
+
+	// The model being edited while a script runs, or nil between runs
+	m *Model
+	// Text printed by the running script
+	printed []string
+}
+
+// newLuaEngine creates a Lua state with the gex module loaded.
+func newLuaEngine() *luaEngine {
+	e := &luaEngine{L: lua.NewState()}
+
+	mod := e.L.SetFuncs(e.L.NewTable(), map[string]lua.LGFunction{
+		"size":          e.size,
+		"read":          e.read,
+		"write":         e.write,
+		"insert":        e.insert,
+		"delete":        e.delete,
+		"cursor":        e.cursor,
+		"set_cursor":    e.setCursor,
+		"selection":     e.selection,
+		"region":        e.region,
+		"clear_regions": e.clearRegions,
+		"prompt":        e.prompt,
+		"print":         e.print,
+		"command":       e.command,
+	})
+	e.L.SetGlobal("gex", mod)
+	e.L.SetGlobal("print", mod.RawGetString("print"))
+
+	return e
+}
+
+// luaEngine returns the model's Lua engine, creating it on first use.
+func (m *Model) luaEngine() *luaEngine {
+	if m.lua == nil {
+		m.lua = newLuaEngine()
+	}
+	return m.lua
+}
+
+// runLua runs fn with the gex module bound to the model. All changes made by
+// the script are grouped into a single undo step, and the printed text or the
+// error is shown in the status bar.
+func (m Model) runLua(fn func(L *lua.LState) error) (Model, tea.Cmd) {
+	e := m.luaEngine()
+	if e.m != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "A script is already running", Error: true})
+	}
+
+	since := len(m.eb.UndoStack)
+	e.m, e.printed = &m, nil
+	err := fn(e.L)
+	e.m = nil
+	m.eb.GroupChanges(since)
+
+	if err != nil {
+		return m, m.quitIfAsked(TeaMsgCmd(StatusTextMsg{Text: "Lua error: " + err.Error(), Error: true}))
+	}
+	if len(e.printed) > 0 {
+		return m, m.quitIfAsked(TeaMsgCmd(StatusTextMsg{Text: strings.Join(e.printed, "; ")}))
+	}
+	return m, m.quitIfAsked(nil)
+}
+
+// handleLuaCommand runs the Lua code given to the lua command.
+func handleLuaCommand(m Model, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: lua <code>"})
+	}
+
+	code := strings.Join(args, " ")
+	return m.runLua(func(L *lua.LState) error {
+		return L.DoString(code)
+	})
+}
+
+// handleSourceCommand runs a script file. Files ending in .lua are run as
+// Lua, and other files as a list of commands like the configuration file. The
+// run command always runs the file as Lua.
+func handleSourceCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: " + command + " <file>"})
+	}
+
+	path := strings.Join(args, " ")
+	if command == "run" || strings.EqualFold(filepath.Ext(path), ".lua") {
+		return m.runLua(func(L *lua.LState) error {
+			return L.DoFile(path)
+		})
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	defer f.Close()
+
+	if err := m.ExecScript(f, path); err != nil {
+		return m, m.quitIfAsked(TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true}))
+	}
+	return m, m.quitIfAsked(nil)
+}
+
+// commit commits a change to the buffer, raising a Lua error if it is outside
+// the buffer.
+func (e *luaEngine) commit(chg core.Change) {
+	if err := e.m.commitChange(chg); err != nil {
+		e.L.RaiseError("%s", err)
+	}
+}
+
+// size implements gex.size(), which returns the size of the buffer.
+func (e *luaEngine) size(L *lua.LState) int {
+	L.Push(lua.LNumber(e.m.eb.Size()))
+	return 1
+}
+
+// read implements gex.read(offset, [length]), which returns up to length
+// bytes at the offset as a string. The length defaults to 1.
+func (e *luaEngine) read(L *lua.LState) int {
+	offset := L.CheckInt64(1)
+	length := L.OptInt64(2, 1)
+	if offset < 0 || length < 0 {
+		L.ArgError(1, "offset and length must not be negative")
+	}
+
+	buf := make([]byte, util.Min(length, util.Max(e.m.eb.Size()-offset, 0)))
+	r := e.m.eb.ReadSeeker()
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		L.RaiseError("%s", err)
+	}
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		L.RaiseError("%s", err)
+	}
+	L.Push(lua.LString(buf[:n]))
+	return 1
+}
+
+// write implements gex.write(offset, data), which overwrites the bytes at the
+// offset. Data reaching past the end extends the buffer.
+func (e *luaEngine) write(L *lua.LState) int {
+	offset := L.CheckInt64(1)
+	data := L.CheckString(2)
+	e.commit(core.Change{
+		Position: offset,
+		Removed:  util.Clamp(e.m.eb.Size()-offset, 0, int64(len(data))),
+		Data:     []byte(data),
+	})
+	return 0
+}
+
+// insert implements gex.insert(offset, data), which inserts bytes before the
+// offset.
+func (e *luaEngine) insert(L *lua.LState) int {
+	offset := L.CheckInt64(1)
+	data := L.CheckString(2)
+	e.commit(core.Change{Position: offset, Data: []byte(data)})
+	return 0
+}
+
+// delete implements gex.delete(offset, length), which deletes bytes at the
+// offset.
+func (e *luaEngine) delete(L *lua.LState) int {
+	offset := L.CheckInt64(1)
+	length := L.CheckInt64(2)
+	e.commit(core.Change{Position: offset, Removed: length})
+	return 0
+}
+
+// cursor implements gex.cursor(), which returns the cursor position.
+func (e *luaEngine) cursor(L *lua.LState) int {
+	L.Push(lua.LNumber(e.m.eb.Cursor))
+	return 1
+}
+
+// setCursor implements gex.set_cursor(offset), which moves the cursor and
+// clears the selection.
+func (e *luaEngine) setCursor(L *lua.LState) int {
+	e.m.SetCursor(L.CheckInt64(1))
+	e.m.eb.SelectionStart = e.m.eb.Cursor
+	return 0
+}
+
+// selection implements gex.selection(), which returns the first and last
+// offset of the selection. Without a selection, both are the cursor.
+func (e *luaEngine) selection(L *lua.LState) int {
+	start, end := e.m.eb.GetSelectionRange()
+	L.Push(lua.LNumber(start))
+	L.Push(lua.LNumber(end))
+	return 2
+}
+
+// region implements gex.region(start, end), which highlights the bytes from
+// start to end inclusive.
+func (e *luaEngine) region(L *lua.LState) int {
+	start := L.CheckInt64(1)
+	end := L.CheckInt64(2)
+	if start < 0 || end < start {
+		L.ArgError(2, "end must not be before start")
+	}
+	e.m.eb.Regions = append(e.m.eb.Regions, core.Region{
+		Type:  core.RegionTypeHighlight,
+		Range: core.Range{Start: start, End: end},
+	})
+	return 0
+}
+
+// clearRegions implements gex.clear_regions(), which removes all highlights.
+func (e *luaEngine) clearRegions(L *lua.LState) int {
+	e.m.eb.Regions = nil
+	return 0
+}
+
+// prompt implements gex.prompt(label, callback), which asks the user for a
+// line of text. The callback is called with the text once the user presses
+// enter, after the current script has finished.
+func (e *luaEngine) prompt(L *lua.LState) int {
+	label := L.CheckString(1)
+	fn := L.CheckFunction(2)
+	e.m.Prompt(label, func(m Model, input string) (Model, tea.Cmd) {
+		return m.runLua(func(L *lua.LState) error {
+			return L.CallByParam(lua.P{Fn: fn, Protect: true}, lua.LString(input))
+		})
+	})
+	return 0
+}
+
+// print implements gex.print(...), which shows its arguments in the status
+// bar. It also replaces the global print function.
+func (e *luaEngine) print(L *lua.LState) int {
+	parts := make([]string, L.GetTop())
+	for i := range parts {
+		parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+	}
+	e.printed = append(e.printed, strings.Join(parts, " "))
+	return 0
+}
+
+// command implements gex.command(line), which runs an editor command such as
+// "set cols=8". Errors from the command are raised as Lua errors.
+func (e *luaEngine) command(L *lua.LState) int {
+	line := L.CheckString(1)
+	if err := e.m.ExecCommand(line); err != nil {
+		L.RaiseError("%s: %s", line, err)
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package display
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// quits returns whether running the command quits the editor.
+func quits(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	switch msg := cmd().(type) {
+	case tea.QuitMsg:
+		return true
+	case tea.BatchMsg:
+		for _, c := range msg {
+			if quits(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestQuitFromScript(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "quit.gex")
+	assert.NoError(os.WriteFile(script, []byte("set cols=8\nq\n"), 0644))
+	luaScript := filepath.Join(dir, "quit.lua")
+	assert.NoError(os.WriteFile(luaScript, []byte("gex.command('q')\n"), 0644))
+
+	var matrix = []struct {
+		name string
+		run  func(m Model) tea.Cmd
+	}{
+		{"source", func(m Model) tea.Cmd {
+			_, cmd := handleCommand(m, "source", []string{script})
+			return cmd
+		}},
+		{"source lua", func(m Model) tea.Cmd {
+			_, cmd := handleCommand(m, "source", []string{luaScript})
+			return cmd
+		}},
+		{"lua", func(m Model) tea.Cmd {
+			_, cmd := handleCommand(m, "lua", []string{"gex.command('q')"})
+			return cmd
+		}},
+		{"rpc", func(m Model) tea.Cmd {
+			call := rpcCall{
+				req:   rpcRequest{JSONRPC: "2.0", Method: "command", Params: []byte(`{"command": "q"}`)},
+				reply: make(chan rpcResponse, 1),
+			}
+			_, cmd := m.handleRPCCall(RPCCallMsg{call: call})
+			return cmd
+		}},
+		{"plugin", func(m Model) tea.Cmd {
+			ed := &pluginEditor{m: &m}
+			assert.NoError(ed.ExecCommand("q"))
+			return ed.result(nil)
+		}},
+	}
+
+	for _, test := range matrix {
+		assert.True(quits(test.run(newTestModel("abc"))), test.name)
+	}
+
+	// Commands that do not quit leave the editor running
+	_, cmd := handleCommand(newTestModel("abc"), "lua", []string{"gex.command('set cols=8')"})
+	assert.False(quits(cmd))
+}
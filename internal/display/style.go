@@ -3,54 +3,85 @@ package display
 import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/muesli/termenv"
 )
 
 var (
-	fgPrimaryColor    = lipgloss.Color("#eeeeee")
-	fgSecondaryColor  = lipgloss.Color("#999999")
-	fgDirtyColor      = lipgloss.Color("#4ade80")
-	bgSelectedColor   = lipgloss.Color("#1e3a8a")
-	bgCursorColor     = lipgloss.Color("#1d4ed8")
-	bgEditingColor    = lipgloss.Color("#7e22ce")
-	bgStatusModeColor = lipgloss.Color("#444444")
-	bgStatusBarColor  = lipgloss.Color("#222222")
-	bgErrorColor      = lipgloss.Color("#ff5555")
+	fgPrimaryColor   lipgloss.TerminalColor
+	fgSecondaryColor lipgloss.TerminalColor
+	bgEditingColor   lipgloss.TerminalColor
+	regionColors     map[core.RegionType]RegionColors
+	byteClassColors  map[util.ByteClass]lipgloss.TerminalColor
+	currentTheme     Theme
+
+	addrStyle          lipgloss.Style
+	textErrorStyle     lipgloss.Style
+	statusDefaultStyle lipgloss.Style
+	statusEditingStyle lipgloss.Style
+	statusBarStyle     lipgloss.Style
+	windowStyle        lipgloss.Style
+	windowTitleStyle   lipgloss.Style
+
+	padLeftStyle = lipgloss.NewStyle().
+			PaddingLeft(1)
+
+	statusStyle map[EditingMode]lipgloss.Style
+)
+
+func init() {
+	applyTheme(builtinThemes[currentThemeName])
+}
+
+// applyTheme builds the styles from the colours of the theme.
+func applyTheme(t Theme) {
+	currentTheme = t
+	fgPrimaryColor = t.Primary.Color()
+	fgSecondaryColor = t.Secondary.Color()
+	bgEditingColor = t.Editing.Color()
+
+	regionColors = make(map[core.RegionType]RegionColors)
+	for name, colors := range t.Regions {
+		regionColors[regionTypeNames[name]] = colors
+	}
+
+	byteClassColors = make(map[util.ByteClass]lipgloss.TerminalColor)
+	for name, color := range t.Bytes {
+		byteClassColors[byteClassNames[name]] = color.Color()
+	}
 
 	addrStyle = lipgloss.NewStyle().
-			Foreground(fgSecondaryColor).
-			Align(lipgloss.Right).
-			PaddingRight(1)
+		Foreground(fgSecondaryColor).
+		Align(lipgloss.Right).
+		PaddingRight(1)
 
 	textErrorStyle = lipgloss.NewStyle().
-			Foreground(bgErrorColor).
-			Bold(true)
+		Foreground(t.Error.Color()).
+		Bold(true)
 	statusDefaultStyle = lipgloss.NewStyle().
-				Foreground(fgPrimaryColor).
-				Background(bgStatusModeColor).
-				Padding(0, 1, 0, 1).
-				Bold(true)
+		Foreground(t.StatusText.Color()).
+		Background(t.StatusMode.Color()).
+		Padding(0, 1, 0, 1).
+		Bold(true)
 	statusEditingStyle = lipgloss.NewStyle().
-				Foreground(fgPrimaryColor).
-				Background(bgEditingColor).
-				PaddingLeft(1).
-				PaddingRight(1).
-				Bold(true)
+		Foreground(t.StatusText.Color()).
+		Background(bgEditingColor).
+		PaddingLeft(1).
+		PaddingRight(1).
+		Bold(true)
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(fgPrimaryColor).
-			Background(bgStatusBarColor)
+		Foreground(t.StatusText.Color()).
+		Background(t.StatusBar.Color())
 	windowStyle = lipgloss.NewStyle().
-			Foreground(fgPrimaryColor).
-			Background(bgStatusBarColor).
-			BorderForeground(fgPrimaryColor).
-			Padding(1, 2, 1, 2)
+		Foreground(t.StatusText.Color()).
+		Background(t.StatusBar.Color()).
+		BorderForeground(t.StatusText.Color()).
+		Padding(1, 2, 1, 2)
 	windowTitleStyle = lipgloss.NewStyle().
-				Foreground(fgPrimaryColor).
-				Background(bgStatusModeColor).
-				Padding(0, 1, 0, 1).
-				Bold(true)
-
-	padLeftStyle = lipgloss.NewStyle().
-			PaddingLeft(1)
+		Foreground(t.StatusText.Color()).
+		Background(t.StatusMode.Color()).
+		Padding(0, 1, 0, 1).
+		Bold(true)
 
 	statusStyle = map[EditingMode]lipgloss.Style{
 		ModeNormal:  statusDefaultStyle,
@@ -59,29 +90,51 @@ var (
 		ModeReplace: statusEditingStyle,
 		ModeCommand: statusDefaultStyle,
 	}
-)
+}
 
-func MakeStyle(primary bool, isEditing bool, activeRegions []core.Region) lipgloss.Style {
+// MakeStyle returns the style of a byte in the hex or text column. If class
+// is not ByteClassNone, the byte is tinted by its class.
+func MakeStyle(primary bool, isEditing bool, class util.ByteClass, activeRegions []core.Region) lipgloss.Style {
 	style := lipgloss.NewStyle()
 
-	if primary {
+	if color, ok := byteClassColors[class]; ok {
+		style = style.Foreground(color).Faint(!primary)
+	} else if primary {
 		style = style.Foreground(fgPrimaryColor)
 	} else {
 		style = style.Foreground(fgSecondaryColor)
 	}
 
+	// Without colours, fall back to text attributes to tell regions apart
+	noColor := lipgloss.ColorProfile() == termenv.Ascii
+
 	for _, r := range activeRegions {
-		switch r.Type {
-		case core.RegionTypeSelection:
-			style = style.Background(bgSelectedColor)
-		case core.RegionTypeCursor:
-			if isEditing {
-				style = style.Background(bgEditingColor)
-			} else {
-				style = style.Background(bgCursorColor)
+		colors, ok := regionColors[r.Type]
+		if !ok {
+			continue
+		}
+
+		if noColor {
+			switch r.Type {
+			case core.RegionTypeCursor:
+				style = style.Reverse(true)
+			case core.RegionTypeSelection, core.RegionTypeHighlight:
+				style = style.Underline(true)
+			case core.RegionTypeDirty:
+				style = style.Bold(true)
 			}
-		case core.RegionTypeDirty:
-			style = style.Foreground(fgDirtyColor)
+			continue
+		}
+
+		if r.Type == core.RegionTypeCursor && isEditing {
+			style = style.Background(bgEditingColor)
+			continue
+		}
+		if colors.Fg != (ThemeColor{}) {
+			style = style.Foreground(colors.Fg.Color())
+		}
+		if colors.Bg != (ThemeColor{}) {
+			style = style.Background(colors.Bg.Color())
 		}
 	}
 
@@ -0,0 +1,278 @@
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/muesli/termenv"
+)
+
+// ThemeColor is a colour with optional fallbacks for terminals that support
+// fewer colours. In a colour scheme file, it can either be written as a
+// single colour string such as "#1e3a8a" or "4", or as an object with the
+// keys "truecolor", "ansi256" and "ansi".
+type ThemeColor struct {
+	TrueColor string `json:"truecolor,omitempty"`
+	ANSI256   string `json:"ansi256,omitempty"`
+	ANSI      string `json:"ansi,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (c *ThemeColor) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = ThemeColor{TrueColor: s}
+		return nil
+	}
+
+	type themeColor ThemeColor
+	return json.Unmarshal(data, (*themeColor)(c))
+}
+
+// Color returns the lipgloss colour. Missing fallbacks are derived from the
+// more precise colours.
+func (c ThemeColor) Color() lipgloss.TerminalColor {
+	if c.TrueColor == "" && c.ANSI256 == "" && c.ANSI == "" {
+		return lipgloss.NoColor{}
+	}
+	if c.ANSI256 == "" && c.ANSI == "" {
+		return lipgloss.Color(c.TrueColor)
+	}
+
+	cc := lipgloss.CompleteColor{TrueColor: c.TrueColor, ANSI256: c.ANSI256, ANSI: c.ANSI}
+	if cc.ANSI256 == "" {
+		cc.ANSI256 = cc.TrueColor
+	}
+	if cc.TrueColor == "" {
+		cc.TrueColor = cc.ANSI256
+	}
+	if cc.ANSI == "" {
+		cc.ANSI = cc.ANSI256
+	}
+	return cc
+}
+
+// RegionColors holds the colours used to highlight a region.
+type RegionColors struct {
+	Fg ThemeColor `json:"fg"`
+	Bg ThemeColor `json:"bg"`
+}
+
+// Theme is a colour scheme.
+type Theme struct {
+	// Base is the name of the theme that this theme is based on. Colours that
+	// are not set in a colour scheme file are taken from the base theme.
+	Base string `json:"base,omitempty"`
+
+	// Colours of the main text, the address column and inactive column
+	Primary   ThemeColor `json:"primary"`
+	Secondary ThemeColor `json:"secondary"`
+
+	// Colours of the status bar
+	StatusText ThemeColor `json:"statusText"`
+	StatusMode ThemeColor `json:"statusMode"`
+	StatusBar  ThemeColor `json:"statusBar"`
+	Editing    ThemeColor `json:"editing"`
+	Error      ThemeColor `json:"error"`
+
+	// Regions holds the colours of each region type, keyed by the region
+	// type's name. The cursor uses the editing colour while editing.
+	Regions map[string]RegionColors `json:"regions"`
+
+	// Bytes holds the colours used to tint bytes by their class when the
+	// colorbytes option is enabled, keyed by the byte class's name.
+	Bytes map[string]ThemeColor `json:"bytes"`
+}
+
+// regionTypeNames maps the names used in colour schemes to region types.
+var regionTypeNames = map[string]core.RegionType{
+	"selection": core.RegionTypeSelection,
+	"cursor":    core.RegionTypeCursor,
+	"dirty":     core.RegionTypeDirty,
+	"highlight": core.RegionTypeHighlight,
+}
+
+// byteClassNames maps the names used in colour schemes to byte classes.
+var byteClassNames = map[string]util.ByteClass{
+	"null":       util.ByteClassNull,
+	"ff":         util.ByteClassFF,
+	"printable":  util.ByteClassPrintable,
+	"whitespace": util.ByteClassWhitespace,
+	"control":    util.ByteClassControl,
+	"high":       util.ByteClassHigh,
+}
+
+// builtinThemes are the colour schemes that ship with gex.
+var builtinThemes = map[string]Theme{
+	"dark": {
+		Primary:    ThemeColor{"#eeeeee", "255", "15"},
+		Secondary:  ThemeColor{"#999999", "246", "7"},
+		StatusText: ThemeColor{"#eeeeee", "255", "15"},
+		StatusMode: ThemeColor{"#444444", "238", "8"},
+		StatusBar:  ThemeColor{"#222222", "235", "0"},
+		Editing:    ThemeColor{"#7e22ce", "91", "5"},
+		Error:      ThemeColor{"#ff5555", "203", "9"},
+		Regions: map[string]RegionColors{
+			"selection": {Bg: ThemeColor{"#1e3a8a", "18", "4"}},
+			"cursor":    {Bg: ThemeColor{"#1d4ed8", "26", "12"}},
+			"dirty":     {Fg: ThemeColor{"#4ade80", "114", "10"}},
+			"highlight": {Bg: ThemeColor{"#854d0e", "94", "3"}},
+		},
+		Bytes: map[string]ThemeColor{
+			"null":       {"#6b7280", "242", "8"},
+			"ff":         {"#f87171", "210", "9"},
+			"printable":  {"#22d3ee", "44", "14"},
+			"whitespace": {"#4ade80", "78", "10"},
+			"control":    {"#e879f9", "177", "13"},
+			"high":       {"#facc15", "220", "11"},
+		},
+	},
+	"light": {
+		Primary:    ThemeColor{"#111827", "233", "0"},
+		Secondary:  ThemeColor{"#6b7280", "243", "8"},
+		StatusText: ThemeColor{"#111827", "233", "0"},
+		StatusMode: ThemeColor{"#d1d5db", "252", "7"},
+		StatusBar:  ThemeColor{"#e5e7eb", "254", "15"},
+		Editing:    ThemeColor{"#d8b4fe", "183", "13"},
+		Error:      ThemeColor{"#dc2626", "160", "1"},
+		Regions: map[string]RegionColors{
+			"selection": {Bg: ThemeColor{"#bfdbfe", "153", "14"}},
+			"cursor":    {Bg: ThemeColor{"#60a5fa", "75", "12"}},
+			"dirty":     {Fg: ThemeColor{"#15803d", "28", "2"}},
+			"highlight": {Bg: ThemeColor{"#fde68a", "222", "11"}},
+		},
+		Bytes: map[string]ThemeColor{
+			"null":       {"#9ca3af", "248", "8"},
+			"ff":         {"#b91c1c", "124", "1"},
+			"printable":  {"#0e7490", "30", "6"},
+			"whitespace": {"#15803d", "28", "2"},
+			"control":    {"#a21caf", "127", "5"},
+			"high":       {"#a16207", "136", "3"},
+		},
+	},
+}
+
+// currentThemeName is the name of the active colour scheme.
+var currentThemeName = "dark"
+
+// colorModes maps the values of the colors option to colour profiles.
+var colorModes = map[string]termenv.Profile{
+	"truecolor": termenv.TrueColor,
+	"256":       termenv.ANSI256,
+	"16":        termenv.ANSI,
+	"none":      termenv.Ascii,
+}
+
+// currentColorMode is the value of the colors option.
+var currentColorMode = "auto"
+
+// setColorMode sets the colour profile used for rendering. The "auto" mode
+// detects the terminal's capabilities and honors the NO_COLOR environment
+// variable.
+func setColorMode(mode string) error {
+	if mode == "auto" {
+		lipgloss.SetColorProfile(lipgloss.DefaultRenderer().Output().EnvColorProfile())
+	} else if profile, ok := colorModes[mode]; ok {
+		lipgloss.SetColorProfile(profile)
+	} else {
+		return fmt.Errorf("expected one of auto, truecolor, 256, 16 or none")
+	}
+
+	currentColorMode = mode
+	return nil
+}
+
+// colorSchemeDir returns the directory that holds the user's colour schemes.
+func colorSchemeDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gex", "colors"), nil
+}
+
+// LoadTheme loads the named colour scheme. The name can either be one of the
+// built-in themes, the name of a JSON file in the colour scheme directory
+// without its extension, or the path to a JSON file.
+func LoadTheme(name string) (Theme, error) {
+	if t, ok := builtinThemes[name]; ok {
+		return t, nil
+	}
+
+	path := name
+	if !strings.ContainsRune(name, filepath.Separator) && !strings.HasSuffix(name, ".json") {
+		dir, err := colorSchemeDir()
+		if err != nil {
+			return Theme{}, err
+		}
+		path = filepath.Join(dir, name+".json")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, fmt.Errorf("cannot find color scheme %s", name)
+	}
+
+	// Read the base theme first so that the scheme overrides it
+	var header struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return Theme{}, fmt.Errorf("invalid color scheme %s: %w", name, err)
+	}
+	if header.Base == "" {
+		header.Base = "dark"
+	}
+	base, ok := builtinThemes[header.Base]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown base color scheme %s", header.Base)
+	}
+
+	t := base
+	t.Regions = make(map[string]RegionColors)
+	for k, v := range base.Regions {
+		t.Regions[k] = v
+	}
+	t.Bytes = make(map[string]ThemeColor)
+	for k, v := range base.Bytes {
+		t.Bytes[k] = v
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return Theme{}, fmt.Errorf("invalid color scheme %s: %w", name, err)
+	}
+	for k := range t.Regions {
+		if _, ok := regionTypeNames[k]; !ok {
+			return Theme{}, fmt.Errorf("invalid color scheme %s: unknown region type %s", name, k)
+		}
+	}
+	for k := range t.Bytes {
+		if _, ok := byteClassNames[k]; !ok {
+			return Theme{}, fmt.Errorf("invalid color scheme %s: unknown byte class %s", name, k)
+		}
+	}
+
+	return t, nil
+}
+
+// listThemes returns the names of all built-in and user colour schemes.
+func listThemes() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	if dir, err := colorSchemeDir(); err == nil {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, f := range files {
+			names = append(names, strings.TrimSuffix(filepath.Base(f), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
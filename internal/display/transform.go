@@ -0,0 +1,153 @@
+package display
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// transformSelection replaces the selection, or the byte under the cursor,
+// with the result of fn as a single change.
+func (m Model) transformSelection(fn func(data []byte) ([]byte, error)) (Model, tea.Cmd) {
+	if m.eb.Size() == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Buffer is empty", Error: true})
+	}
+
+	start, end := m.eb.GetSelectionRange()
+	data, err := m.readBytes(start, end-start+1)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	out, err := fn(data)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if err := m.commitChange(core.Change{
+		Position: start,
+		Removed:  int64(len(data)),
+		Data:     out,
+	}); err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	if len(out) != len(data) {
+		m.SetCursor(m.eb.Cursor)
+		m.eb.SelectionStart = util.Min(m.eb.SelectionStart, m.eb.Cursor)
+		return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Transformed %d bytes into %d bytes", len(data), len(out))})
+	}
+	return m, TeaMsgCmd(StatusTextMsg{Text: fmt.Sprintf("Transformed %d bytes", len(data))})
+}
+
+// parseWordArgs parses the optional word size and byte order following the
+// operand of a word transform, in either order.
+func (m Model) parseWordArgs(args []string, size int) (int, binary.ByteOrder, error) {
+	order := m.inspectorByteOrder
+	for _, arg := range args {
+		if n, err := util.ParseNumber(arg, 10); err == nil {
+			size = int(n)
+			continue
+		}
+		o, err := util.ParseByteOrder(arg)
+		if err != nil {
+			return 0, nil, fmt.Errorf("expected a word size or byte order: %s", arg)
+		}
+		order = o
+	}
+	return size, order, nil
+}
+
+// handleKeyTransformCommand implements xor, and and or, which combine the
+// selection with a repeating key.
+func handleKeyTransformCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: " + command + " <key>"})
+	}
+	key, err := util.ParsePattern(strings.Join(args, " "))
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	op := map[string]func(data, key []byte) []byte{
+		"xor": util.XOR,
+		"and": util.AND,
+		"or":  util.OR,
+	}[command]
+	return m.transformSelection(func(data []byte) ([]byte, error) {
+		return op(data, key), nil
+	})
+}
+
+// handleArithCommand implements add and sub, which add to or subtract from
+// every word of the selection.
+func handleArithCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 || len(args) > 3 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: " + command + " <value> [size] [le|be]"})
+	}
+	delta, err := util.ParseNumber(args[0], 10)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+	size, order, err := m.parseWordArgs(args[1:], 1)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	op := util.AddWords
+	if command == "sub" {
+		op = util.SubWords
+	}
+	return m.transformSelection(func(data []byte) ([]byte, error) {
+		return op(data, delta, size, order)
+	})
+}
+
+// handleBitShiftCommand implements rol, ror, shl and shr, which rotate or
+// shift the bits of every word of the selection.
+func handleBitShiftCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) == 0 || len(args) > 3 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: " + command + " <bits> [size] [le|be]"})
+	}
+	bits, err := util.ParseNumber(args[0], 10)
+	if err != nil || bits > 64 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Invalid bit count: " + args[0], Error: true})
+	}
+	size, order, err := m.parseWordArgs(args[1:], 1)
+	if err != nil {
+		return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+	}
+
+	n := int(bits)
+	if command == "ror" || command == "shr" {
+		n = -n
+	}
+	op := util.RotateWords
+	if command == "shl" || command == "shr" {
+		op = util.ShiftWords
+	}
+	return m.transformSelection(func(data []byte) ([]byte, error) {
+		return op(data, n, size, order)
+	})
+}
+
+// handleByteSwapCommand reverses the bytes within every word of the
+// selection. The word size defaults to 2.
+func handleByteSwapCommand(m Model, command string, args []string) (Model, tea.Cmd) {
+	if len(args) > 1 {
+		return m, TeaMsgCmd(StatusTextMsg{Text: "Usage: bswap [size]"})
+	}
+	size := uint64(2)
+	if len(args) > 0 {
+		var err error
+		if size, err = util.ParseNumber(args[0], 10); err != nil {
+			return m, TeaMsgCmd(StatusTextMsg{Text: err.Error(), Error: true})
+		}
+	}
+
+	return m.transformSelection(func(data []byte) ([]byte, error) {
+		return util.SwapBytes(data, int(size))
+	})
+}
@@ -20,6 +20,10 @@ type Change struct {
 	// The data inserted at the position. If longer than Removed, the extra
 	// bytes will replace the bytes at Position + Removed.
 	Data []byte
+
+	// Group identifies changes that are undone and redone together. Changes
+	// sharing the same non-zero Group form a single undo step.
+	Group int
 }
 
 // ReadSeeker returns a ReadSeeker with the Change applied to the given
@@ -0,0 +1,248 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// RulePosition is an offset in a buffer, counted from the start or, if
+// FromEnd is set, back from the end.
+type RulePosition struct {
+	Offset  int64
+	FromEnd bool
+}
+
+// Resolve returns the offset from the start of a buffer of the given size.
+func (p RulePosition) Resolve(size int64) int64 {
+	if p.FromEnd {
+		return size - p.Offset
+	}
+	return p.Offset
+}
+
+func (p RulePosition) String() string {
+	switch {
+	case p.FromEnd && p.Offset == 0:
+		return "EOF"
+	case p.FromEnd:
+		return fmt.Sprintf("EOF-0x%x", p.Offset)
+	}
+	return fmt.Sprintf("0x%x", p.Offset)
+}
+
+// parseRulePosition parses an offset such as "0x100", "EOF" or "EOF-4".
+// Numbers are decimal unless prefixed.
+func parseRulePosition(s string) (RulePosition, error) {
+	var p RulePosition
+	str := s
+	if len(str) >= 3 && strings.EqualFold(str[:3], "eof") {
+		p.FromEnd = true
+		str = strings.TrimSpace(str[3:])
+		if str == "" {
+			return p, nil
+		}
+		if !strings.HasPrefix(str, "-") {
+			return p, fmt.Errorf("invalid position: %s", s)
+		}
+		str = str[1:]
+	}
+
+	n, err := util.ParseNumber(str, 10)
+	if err != nil || n > 1<<62 {
+		return p, fmt.Errorf("invalid position: %s", s)
+	}
+	p.Offset = int64(n)
+	return p, nil
+}
+
+// ChecksumRule is a checksum that is stored in the buffer, such as a CRC32 of
+// a firmware image stored in its header.
+type ChecksumRule struct {
+	// Algorithm is the name of the algorithm, as accepted by util.NewHash.
+	Algorithm string
+
+	// Start and End are the range the checksum is computed over. End is
+	// exclusive.
+	Start, End RulePosition
+
+	// At is where the checksum is stored.
+	At RulePosition
+
+	// ByteOrder is the byte order the checksum is stored in. It is ignored
+	// for digests longer than 8 bytes.
+	ByteOrder binary.ByteOrder
+}
+
+// ParseChecksumRule parses a rule such as "crc32 of 0x100..EOF stored le at
+// 0xfc". The words "of", "stored" and "at" are optional, and the byte order
+// and the position can be given in either order. The byte order defaults to
+// little endian.
+func ParseChecksumRule(s string) (ChecksumRule, error) {
+	r := ChecksumRule{ByteOrder: binary.LittleEndian}
+	fields := make([]string, 0)
+	for _, f := range strings.Fields(s) {
+		switch strings.ToLower(f) {
+		case "of", "stored", "at":
+		default:
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) < 3 || len(fields) > 4 {
+		return r, fmt.Errorf("expected <algorithm> <start>..<end> <position> [le|be], got %q", s)
+	}
+
+	r.Algorithm = strings.ToLower(fields[0])
+	if _, err := util.NewHash(r.Algorithm); err != nil {
+		return r, err
+	}
+
+	startStr, endStr, ok := strings.Cut(fields[1], "..")
+	if !ok {
+		return r, fmt.Errorf("expected a range such as 0x100..EOF, got %q", fields[1])
+	}
+	var err error
+	if r.Start, err = parseRulePosition(startStr); err != nil {
+		return r, err
+	}
+	if r.End, err = parseRulePosition(endStr); err != nil {
+		return r, err
+	}
+
+	hasAt := false
+	for _, f := range fields[2:] {
+		if order, err := util.ParseByteOrder(f); err == nil {
+			r.ByteOrder = order
+			continue
+		}
+		if hasAt {
+			return r, fmt.Errorf("unexpected %q in %q", f, s)
+		}
+		if r.At, err = parseRulePosition(f); err != nil {
+			return r, err
+		}
+		hasAt = true
+	}
+	if !hasAt {
+		return r, fmt.Errorf("no position given in %q", s)
+	}
+	return r, nil
+}
+
+func (r ChecksumRule) String() string {
+	order := "le"
+	if r.ByteOrder == binary.BigEndian {
+		order = "be"
+	}
+	return fmt.Sprintf("%s of %s..%s stored %s at %s", r.Algorithm, r.Start, r.End, order, r.At)
+}
+
+// ParseChecksumRules parses one rule per line. Blank lines and lines starting
+// with '#' are ignored.
+func ParseChecksumRules(rd io.Reader) ([]ChecksumRule, error) {
+	rules := make([]ChecksumRule, 0)
+	sc := bufio.NewScanner(rd)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r, err := ParseChecksumRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, sc.Err()
+}
+
+// ChecksumRulesPath returns the path of the file holding the checksum rules
+// of a file, which is the file's name followed by ".gex".
+func ChecksumRulesPath(name string) string {
+	return name + ".gex"
+}
+
+// LoadChecksumRules loads the checksum rules of a file. A file without a
+// rules file has no rules.
+func LoadChecksumRules(name string) ([]ChecksumRule, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	path := ChecksumRulesPath(name)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rules, err := ParseChecksumRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// Checksum computes the checksum described by the rule and returns it in
+// the rule's byte order, along with the offset it is stored at.
+func (b *EditorBuffer) Checksum(r ChecksumRule) ([]byte, int64, error) {
+	size := b.Size()
+	start, end, at := r.Start.Resolve(size), r.End.Resolve(size), r.At.Resolve(size)
+	if start < 0 || end < start || end > size {
+		return nil, 0, fmt.Errorf("range is outside the buffer (size %d)", size)
+	}
+
+	h, err := util.NewHash(r.Algorithm)
+	if err != nil {
+		return nil, 0, err
+	}
+	if at < 0 || at+int64(h.Size()) > size {
+		return nil, 0, fmt.Errorf("position is outside the buffer (size %d)", size)
+	}
+	if at < end && at+int64(h.Size()) > start {
+		return nil, 0, fmt.Errorf("checksum is stored inside its own range")
+	}
+
+	rs := b.ReadSeeker()
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	if _, err := io.CopyN(h, rs, end-start); err != nil {
+		return nil, 0, err
+	}
+
+	return util.OrderSum(h.Sum(nil), r.ByteOrder), at, nil
+}
+
+// ChecksumChange returns the change that stores the correct checksum for the
+// rule, or nil if the stored checksum is already correct.
+func (b *EditorBuffer) ChecksumChange(r ChecksumRule) (*Change, error) {
+	sum, at, err := b.Checksum(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make([]byte, len(sum))
+	rs := b.ReadSeeker()
+	if _, err := rs.Seek(at, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rs, stored); err != nil {
+		return nil, err
+	}
+	if bytes.Equal(stored, sum) {
+		return nil, nil
+	}
+	return &Change{Position: at, Removed: int64(len(sum)), Data: sum}, nil
+}
@@ -0,0 +1,87 @@
+package core_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChecksumRule(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected string
+		err      bool
+	}{
+		{"CRC32 of 0x100..EOF stored LE at 0xFC", "crc32 of 0x100..EOF stored le at 0xfc", false},
+		{"crc16 0..16 16 be", "crc16 of 0x0..0x10 stored be at 0x10", false},
+		{"sum8 0..EOF-1 EOF-1", "sum8 of 0x0..EOF-0x1 stored le at EOF-0x1", false},
+		{"sha256 of 0..eof-32 at eof-32", "sha256 of 0x0..EOF-0x20 stored le at EOF-0x20", false},
+		{"crc16:init=ffff 4..8 0 big", "crc16:init=ffff of 0x4..0x8 stored be at 0x0", false},
+		{"crc32 0x100..EOF", "", true},
+		{"crc32 0x100 0xfc", "", true},
+		{"crc32 0x100..EOF+1 0xfc", "", true},
+		{"crc32 0..4 4 8", "", true},
+		{"whirlpool 0..4 4", "", true},
+	}
+
+	for _, m := range matrix {
+		r, err := core.ParseChecksumRule(m.inp)
+		if m.err {
+			assert.Error(err, m.inp)
+			continue
+		}
+		if assert.NoError(err, m.inp) {
+			assert.Equal(m.expected, r.String(), m.inp)
+		}
+	}
+}
+
+func TestEditorBuffer_ChecksumChange(t *testing.T) {
+	assert := assert.New(t)
+
+	rules, err := core.ParseChecksumRules(strings.NewReader(`# header checksum
+crc32 of 4..EOF-1 stored le at 0
+
+sum8 of 0..EOF-1 stored at EOF-1
+`))
+	assert.NoError(err)
+	assert.Len(rules, 2)
+	assert.Equal(binary.LittleEndian, rules[0].ByteOrder)
+
+	eb := core.NewEditorBuffer("", bytes.NewReader([]byte("\x00\x00\x00\x00123456789\x00")))
+	for _, r := range rules {
+		chg, err := eb.ChecksumChange(r)
+		assert.NoError(err)
+		if assert.NotNil(chg) {
+			eb.PreviewChange(chg)
+			eb.CommitChange()
+		}
+	}
+	assert.Equal([]byte("\x26\x39\xf4\xcb123456789\xfb"), readAll(t, eb))
+
+	// Correct checksums need no change
+	for _, r := range rules {
+		chg, err := eb.ChecksumChange(r)
+		assert.NoError(err)
+		assert.Nil(chg)
+	}
+
+	// Rules that do not fit the buffer are errors
+	for _, s := range []string{"crc32 0..4 EOF-2", "crc32 0..EOF 0", "crc32 8..4 0", "sum8 0..EOF+0 0"} {
+		r, err := core.ParseChecksumRule(s)
+		if err != nil {
+			continue
+		}
+		_, err = eb.ChecksumChange(r)
+		assert.Error(err, s)
+	}
+
+	_, err = core.ParseChecksumRules(strings.NewReader("crc32 0..4 4\ncrc32 0..4\n"))
+	assert.ErrorContains(err, "line 2")
+}
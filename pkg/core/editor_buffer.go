@@ -25,6 +25,10 @@ type EditorBuffer struct {
 	// The underlying buffer containing the actual data.
 	Buffer io.ReadSeeker
 
+	// Format reads and writes the file backing the buffer, or nil if the file
+	// holds the contents as they are.
+	Format FileFormat
+
 	// Clipboard holds the current clipboard contents.
 	Clipboard []byte
 
@@ -43,6 +47,12 @@ type EditorBuffer struct {
 	// Regions is a list of user-defined regions in the buffer. This does not
 	// include the selection and other internal regions.
 	Regions []Region
+
+	// lastGroup is the last undo group ID handed out by GroupChanges.
+	lastGroup int
+
+	// revision is incremented whenever the contents may have changed.
+	revision int
 }
 
 // NewEditorBuffer creates a new EditorBuffer with the given name and buffer.
@@ -64,15 +74,22 @@ func (b *EditorBuffer) Reload() error {
 		f.Close()
 	}
 
-	f, err := os.Open(b.Name)
+	var r io.ReadSeeker
+	var err error
+	if b.Format != nil {
+		r, err = b.Format.Open(b.Name)
+	} else {
+		r, err = os.Open(b.Name)
+	}
 	if err != nil {
 		return err
 	}
 
-	b.Buffer = f
+	b.Buffer = r
 	b.UndoStack = make([]Change, 0)
 	b.RedoStack = make([]Change, 0)
 	b.Preview = nil
+	b.revision++
 
 	return nil
 }
@@ -95,37 +112,76 @@ func (b *EditorBuffer) ReadSeeker() io.ReadSeeker {
 	return r
 }
 
-// Undo undoes the last change.
+// Undo undoes the last change. If the change is part of a group, the whole
+// group is undone.
 func (b *EditorBuffer) Undo() bool {
 	if len(b.UndoStack) == 0 {
 		return false
 	}
 
-	// Move the last change from the undo stack to the redo stack
-	chg := b.UndoStack[len(b.UndoStack)-1]
-	b.UndoStack = b.UndoStack[:len(b.UndoStack)-1]
-	b.RedoStack = append(b.RedoStack, chg)
+	b.revision++
+	group := b.UndoStack[len(b.UndoStack)-1].Group
+	for len(b.UndoStack) > 0 {
+		// Move the last change from the undo stack to the redo stack
+		chg := b.UndoStack[len(b.UndoStack)-1]
+		if group != 0 && chg.Group != group {
+			break
+		}
+		b.UndoStack = b.UndoStack[:len(b.UndoStack)-1]
+		b.RedoStack = append(b.RedoStack, chg)
+		if group == 0 {
+			break
+		}
+	}
 
 	return true
 }
 
-// Redo redoes the last change.
+// Redo redoes the last change. If the change is part of a group, the whole
+// group is redone.
 func (b *EditorBuffer) Redo() bool {
 	if len(b.RedoStack) == 0 {
 		return false
 	}
 
-	// Move the last change from the redo stack to the undo stack
-	chg := b.RedoStack[len(b.RedoStack)-1]
-	b.RedoStack = b.RedoStack[:len(b.RedoStack)-1]
-	b.UndoStack = append(b.UndoStack, chg)
+	b.revision++
+	group := b.RedoStack[len(b.RedoStack)-1].Group
+	for len(b.RedoStack) > 0 {
+		// Move the last change from the redo stack to the undo stack
+		chg := b.RedoStack[len(b.RedoStack)-1]
+		if group != 0 && chg.Group != group {
+			break
+		}
+		b.RedoStack = b.RedoStack[:len(b.RedoStack)-1]
+		b.UndoStack = append(b.UndoStack, chg)
+		if group == 0 {
+			break
+		}
+	}
 
 	return true
 }
 
+// GroupChanges merges all changes on the undo stack from index since onwards
+// into a single undo step.
+func (b *EditorBuffer) GroupChanges(since int) {
+	if since < 0 {
+		since = 0
+	}
+	if len(b.UndoStack)-since < 2 {
+		return
+	}
+
+	b.lastGroup++
+	for i := since; i < len(b.UndoStack); i++ {
+		b.UndoStack[i].Group = b.lastGroup
+	}
+}
+
 // PreviewChange applies the given change to the preview buffer.
 func (b *EditorBuffer) PreviewChange(chg *Change) {
 	b.Preview = chg
+	b.revision++
 }
 
 // CommitChange commits the preview change to the buffer.
@@ -144,11 +200,37 @@ func (b *EditorBuffer) CommitChange() {
 	b.RedoStack = make([]Change, 0)
 }
 
+// Revision returns a number that changes whenever the contents of the buffer
+// may have changed. It can be used to cache values computed from the contents.
+func (b *EditorBuffer) Revision() int {
+	return b.revision
+}
+
 // IsDirty returns true if the buffer contains unsaved changes.
 func (b *EditorBuffer) IsDirty() bool {
 	return len(b.UndoStack) > 0 || b.Preview != nil
 }
 
+// Holes returns the ranges of the buffer without any data, sorted by
+// position, if the underlying buffer is sparse. Holes move along with the
+// changes around them, and bytes written into a hole are no longer part of
+// it.
+func (b *EditorBuffer) Holes() []Range {
+	sparse, ok := b.Buffer.(Sparse)
+	if !ok {
+		return nil
+	}
+
+	holes := sparse.Holes()
+	for i := range b.UndoStack {
+		holes = b.UndoStack[i].applyToHoles(holes)
+	}
+	if b.Preview != nil {
+		holes = b.Preview.applyToHoles(holes)
+	}
+	return holes
+}
+
 // GetSelectionRange returns the start and end of the current selection.
 func (b *EditorBuffer) GetSelectionRange() (int64, int64) {
 	if b.Cursor < b.SelectionStart {
@@ -268,9 +350,9 @@ func (b *EditorBuffer) GetRegions() []Region {
 	return regions
 }
 
-// WriteToFile writes the buffer contents to the given file. Do not call this
-// with the same file that is backing the buffer. To safely save the buffer to
-// the same file, use Save.
+// WriteToFile writes the buffer contents to the given file, in the buffer's
+// format if it has one. Do not call this with the same file that is backing
+// the buffer. To safely save the buffer to the same file, use Save.
 func (b *EditorBuffer) WriteToFile(filename string) (int64, error) {
 	f, err := os.Create(filename)
 	if err != nil {
@@ -278,6 +360,12 @@ func (b *EditorBuffer) WriteToFile(filename string) (int64, error) {
 	}
 	defer f.Close()
 
+	if b.Format != nil {
+		cw := &countingWriter{w: f}
+		err := b.Format.Write(cw, b.ReadSeeker(), b.Size(), b.Holes())
+		return cw.n, err
+	}
+
 	rs := b.ReadSeeker()
 	_, err = rs.Seek(0, io.SeekStart)
 	if err != nil {
@@ -287,9 +375,26 @@ func (b *EditorBuffer) WriteToFile(filename string) (int64, error) {
 	return io.Copy(f, rs)
 }
 
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
 // SaveInPlace will modify the edited file in-place. This will only work if the
-// changes do not change the size of the file.
+// changes do not change the size of the file, and the file holds the contents
+// as they are.
 func (b *EditorBuffer) SaveInPlace() error {
+	if b.Format != nil {
+		return fmt.Errorf("cannot save a file in a format in place")
+	}
+
 	// Check if the changes modify the file size
 	for _, chg := range b.UndoStack {
 		if chg.Removed != int64(len(chg.Data)) {
@@ -0,0 +1,52 @@
+package core_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, eb *core.EditorBuffer) []byte {
+	rs := eb.ReadSeeker()
+	_, err := rs.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+	b, err := io.ReadAll(rs)
+	if err != io.EOF {
+		assert.NoError(t, err)
+	}
+	return b
+}
+
+func TestEditorBuffer_GroupChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	eb := core.NewEditorBuffer("", bytes.NewReader([]byte("0123456789")))
+	commit := func(chg core.Change) {
+		eb.PreviewChange(&chg)
+		eb.CommitChange()
+	}
+
+	commit(core.Change{Position: 0, Removed: 1, Data: []byte("a")})
+	since := len(eb.UndoStack)
+	commit(core.Change{Position: 1, Removed: 1, Data: []byte("b")})
+	commit(core.Change{Position: 2, Removed: 1, Data: []byte("c")})
+	eb.GroupChanges(since)
+	assert.Equal([]byte("abc3456789"), readAll(t, eb))
+
+	// Undoing the group reverts both grouped changes at once
+	assert.True(eb.Undo())
+	assert.Equal([]byte("a123456789"), readAll(t, eb))
+
+	// Redoing restores the whole group
+	assert.True(eb.Redo())
+	assert.Equal([]byte("abc3456789"), readAll(t, eb))
+
+	// Ungrouped changes are still undone one at a time
+	assert.True(eb.Undo())
+	assert.True(eb.Undo())
+	assert.Equal([]byte("0123456789"), readAll(t, eb))
+	assert.False(eb.Undo())
+}
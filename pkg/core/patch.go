@@ -0,0 +1,160 @@
+package core
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// PatchOp is the kind of edit made by a Patch.
+type PatchOp int
+
+const (
+	// PatchSet overwrites bytes at the offset.
+	PatchSet PatchOp = iota
+
+	// PatchInsert inserts bytes before the offset.
+	PatchInsert
+
+	// PatchDelete deletes bytes starting at the offset.
+	PatchDelete
+)
+
+func (op PatchOp) String() string {
+	switch op {
+	case PatchSet:
+		return "set"
+	case PatchInsert:
+		return "insert"
+	case PatchDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// Patch is a single edit to a buffer, as given on the command line or in a
+// patch script.
+type Patch struct {
+	Op     PatchOp
+	Offset int64
+
+	// Data holds the bytes to write for PatchSet and PatchInsert.
+	Data []byte
+
+	// Length is the number of bytes to remove for PatchDelete.
+	Length int64
+}
+
+// ParsePatch parses a patch argument for the given operation. Set and insert
+// take "offset=hex", and delete takes "offset:length". Offsets and lengths
+// are decimal unless prefixed, such as 0x1234. Spaces in the hex are ignored.
+func ParsePatch(op string, arg string) (Patch, error) {
+	var p Patch
+	switch op {
+	case "set":
+		p.Op = PatchSet
+	case "insert":
+		p.Op = PatchInsert
+	case "delete":
+		p.Op = PatchDelete
+	default:
+		return p, fmt.Errorf("unknown patch operation: %s", op)
+	}
+
+	sep := "="
+	if p.Op == PatchDelete {
+		sep = ":"
+	}
+	offStr, value, ok := strings.Cut(arg, sep)
+	if !ok {
+		if p.Op == PatchDelete {
+			return p, fmt.Errorf("expected offset:length, got %q", arg)
+		}
+		return p, fmt.Errorf("expected offset=hex, got %q", arg)
+	}
+
+	offset, err := util.ParseNumber(offStr, 10)
+	if err != nil {
+		return p, fmt.Errorf("invalid offset: %w", err)
+	}
+	p.Offset = int64(offset)
+
+	if p.Op == PatchDelete {
+		length, err := util.ParseNumber(value, 10)
+		if err != nil {
+			return p, fmt.Errorf("invalid length: %w", err)
+		}
+		p.Length = int64(length)
+		return p, nil
+	}
+
+	hexStr := strings.Join(strings.Fields(value), "")
+	hexStr = strings.TrimPrefix(strings.TrimPrefix(hexStr, "0x"), "0X")
+	p.Data, err = hex.DecodeString(hexStr)
+	if err != nil {
+		return p, fmt.Errorf("invalid hex %q: %w", value, err)
+	}
+	if len(p.Data) == 0 {
+		return p, fmt.Errorf("no bytes given for %s at %d", op, p.Offset)
+	}
+	return p, nil
+}
+
+// ParsePatchScript parses a patch script. Each line holds an operation and
+// its argument, such as "set 0x1234=deadbeef", "insert 0x20=00ff" or
+// "delete 0x40:16". Blank lines and lines starting with '#' are ignored.
+func ParsePatchScript(r io.Reader) ([]Patch, error) {
+	patches := make([]Patch, 0)
+	sc := bufio.NewScanner(r)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		op, arg, _ := strings.Cut(line, " ")
+		p, err := ParsePatch(op, strings.TrimSpace(arg))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		patches = append(patches, p)
+	}
+	return patches, sc.Err()
+}
+
+// Change returns the change that applies the patch.
+func (p Patch) Change() Change {
+	switch p.Op {
+	case PatchInsert:
+		return Change{Position: p.Offset, Data: p.Data}
+	case PatchDelete:
+		return Change{Position: p.Offset, Removed: p.Length}
+	}
+	return Change{Position: p.Offset, Removed: int64(len(p.Data)), Data: p.Data}
+}
+
+// ApplyPatch commits the patch to the buffer. Offsets refer to the buffer as
+// left by the previous patches. An error is returned if the patch reaches
+// past the end of the buffer.
+func (b *EditorBuffer) ApplyPatch(p Patch) error {
+	size := b.Size()
+	end := p.Offset
+	switch p.Op {
+	case PatchSet:
+		end += int64(len(p.Data))
+	case PatchDelete:
+		end += p.Length
+	}
+	if p.Offset < 0 || end > size {
+		return fmt.Errorf("%s at %d is past the end of the buffer (size %d)", p.Op, p.Offset, size)
+	}
+
+	chg := p.Change()
+	b.PreviewChange(&chg)
+	b.CommitChange()
+	return nil
+}
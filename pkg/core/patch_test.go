@@ -0,0 +1,69 @@
+package core_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePatch(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		op       string
+		arg      string
+		expected core.Patch
+		err      bool
+	}{
+		{"set", "0x1234=deadbeef", core.Patch{Op: core.PatchSet, Offset: 0x1234, Data: []byte{0xde, 0xad, 0xbe, 0xef}}, false},
+		{"set", "16=de ad", core.Patch{Op: core.PatchSet, Offset: 16, Data: []byte{0xde, 0xad}}, false},
+		{"insert", "0x20=0x00ff", core.Patch{Op: core.PatchInsert, Offset: 0x20, Data: []byte{0x00, 0xff}}, false},
+		{"delete", "0x40:16", core.Patch{Op: core.PatchDelete, Offset: 0x40, Length: 16}, false},
+		{"set", "0x10", core.Patch{}, true},
+		{"set", "0x10=xyz", core.Patch{}, true},
+		{"set", "0x10=", core.Patch{}, true},
+		{"delete", "0x10=4", core.Patch{}, true},
+		{"replace", "0=00", core.Patch{}, true},
+	}
+
+	for _, m := range matrix {
+		p, err := core.ParsePatch(m.op, m.arg)
+		if m.err {
+			assert.Error(err, "%s %s", m.op, m.arg)
+			continue
+		}
+		assert.NoError(err, "%s %s", m.op, m.arg)
+		assert.Equal(m.expected, p, "%s %s", m.op, m.arg)
+	}
+}
+
+func TestEditorBuffer_ApplyPatch(t *testing.T) {
+	assert := assert.New(t)
+
+	script := `# patch the header
+set 0=4142
+
+insert 4=00ff
+delete 0x8:2
+`
+	patches, err := core.ParsePatchScript(strings.NewReader(script))
+	assert.NoError(err)
+	assert.Len(patches, 3)
+
+	eb := core.NewEditorBuffer("", bytes.NewReader([]byte("0123456789")))
+	for _, p := range patches {
+		assert.NoError(eb.ApplyPatch(p))
+	}
+	assert.Equal([]byte("AB23\x00\xff4589"), readAll(t, eb))
+
+	// Patches reaching past the end are rejected
+	assert.Error(eb.ApplyPatch(core.Patch{Op: core.PatchSet, Offset: 9, Data: []byte("xy")}))
+	assert.Error(eb.ApplyPatch(core.Patch{Op: core.PatchDelete, Offset: 10, Length: 1}))
+	assert.NoError(eb.ApplyPatch(core.Patch{Op: core.PatchInsert, Offset: 10, Data: []byte("!")}))
+
+	_, err = core.ParsePatchScript(strings.NewReader("set 0=00\nfill 0=00\n"))
+	assert.ErrorContains(err, "line 2")
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// Sparse is implemented by buffers that have holes, which are ranges without
+// any data, such as the gaps between the records of an Intel HEX file or the
+// unmapped pages of a process.
+type Sparse interface {
+	// Holes returns the holes of the buffer, sorted by position.
+	Holes() []Range
+}
+
+// FileFormat reads and writes the file backing a buffer when it is stored in
+// another form than its contents, such as a text format.
+type FileFormat interface {
+	// Open opens the file and returns its contents.
+	Open(name string) (io.ReadSeeker, error)
+
+	// Write writes the contents of a buffer in the format. The holes of the
+	// buffer are left out.
+	Write(w io.Writer, r io.ReadSeeker, size int64, holes []Range) error
+}
+
+// Extent is a block of data in a sparse buffer.
+type Extent struct {
+	Offset int64
+	Data   []byte
+}
+
+// SparseReader is a ReadSeeker over extents of data separated by holes. Holes
+// read as the fill byte.
+type SparseReader struct {
+	extents []Extent
+	size    int64
+	fill    byte
+	p       int64
+}
+
+var _ io.ReadSeeker = &SparseReader{}
+var _ Sparse = &SparseReader{}
+
+// NewSparseReader creates a SparseReader over the extents, which must not
+// overlap. The size of the buffer is the end of the last extent.
+func NewSparseReader(extents []Extent, fill byte) (*SparseReader, error) {
+	extents = append([]Extent{}, extents...)
+	sort.SliceStable(extents, func(i, j int) bool { return extents[i].Offset < extents[j].Offset })
+
+	size := int64(0)
+	for _, e := range extents {
+		if e.Offset < size {
+			return nil, fmt.Errorf("extent at %d overlaps the previous one", e.Offset)
+		}
+		size = e.Offset + int64(len(e.Data))
+	}
+	return &SparseReader{extents: extents, size: size, fill: fill}, nil
+}
+
+func (r *SparseReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		r.p = offset
+	case io.SeekCurrent:
+		r.p += offset
+	case io.SeekEnd:
+		r.p = r.size + offset
+	}
+
+	if r.p < 0 {
+		return r.p, io.EOF
+	}
+
+	return r.p, nil
+}
+
+func (r *SparseReader) Read(out []byte) (int, error) {
+	if r.p >= r.size {
+		return 0, io.EOF
+	}
+
+	// Find the first extent that ends after the position
+	i := sort.Search(len(r.extents), func(i int) bool {
+		return r.extents[i].Offset+int64(len(r.extents[i].Data)) > r.p
+	})
+
+	n := 0
+	for n < len(out) && r.p < r.size {
+		e := r.extents[i]
+		if r.p < e.Offset {
+			// Fill the hole up to the extent
+			k := int(util.Min(e.Offset-r.p, int64(len(out)-n)))
+			for j := 0; j < k; j++ {
+				out[n+j] = r.fill
+			}
+			n += k
+			r.p += int64(k)
+			continue
+		}
+
+		k := copy(out[n:], e.Data[r.p-e.Offset:])
+		n += k
+		r.p += int64(k)
+		i++
+	}
+
+	return n, nil
+}
+
+// Holes returns the ranges between the extents.
+func (r *SparseReader) Holes() []Range {
+	holes := make([]Range, 0)
+	end := int64(0)
+	for _, e := range r.extents {
+		if e.Offset > end {
+			holes = append(holes, Range{Start: end, End: e.Offset - 1})
+		}
+		end = e.Offset + int64(len(e.Data))
+	}
+	return holes
+}
+
+// Extents returns the ranges of a buffer of the given size that are not
+// holes, which is the inverse of the holes. Holes past the size are ignored.
+func Extents(size int64, holes []Range) []Range {
+	extents := make([]Range, 0, len(holes)+1)
+	start := int64(0)
+	for _, h := range holes {
+		if h.Start >= size {
+			break
+		}
+		if h.Start > start {
+			extents = append(extents, Range{Start: start, End: h.Start - 1})
+		}
+		start = h.End + 1
+	}
+	if start < size {
+		extents = append(extents, Range{Start: start, End: size - 1})
+	}
+	return extents
+}
+
+// IsHole returns whether the position is inside one of the holes, which must
+// be sorted.
+func IsHole(holes []Range, pos int64) bool {
+	i := sort.Search(len(holes), func(i int) bool { return holes[i].End >= pos })
+	return i < len(holes) && holes[i].Start <= pos
+}
+
+// applyToHoles moves the holes as the change moves the bytes around them.
+// Removed bytes take their part of a hole with them, and inserted bytes are
+// never holes.
+func (c *Change) applyToHoles(holes []Range) []Range {
+	removedEnd := c.Position + c.Removed
+	shift := int64(len(c.Data)) - c.Removed
+
+	out := make([]Range, 0, len(holes)+1)
+	add := func(h Range) {
+		// Join the parts of a hole around bytes that were only removed
+		if n := len(out); n > 0 && out[n-1].End+1 == h.Start {
+			out[n-1].End = h.End
+			return
+		}
+		out = append(out, h)
+	}
+	for _, h := range holes {
+		if h.Start < c.Position {
+			add(Range{Start: h.Start, End: util.Min(h.End, c.Position-1)})
+		}
+		if h.End >= removedEnd {
+			add(Range{Start: util.Max(h.Start, removedEnd) + shift, End: h.End + shift})
+		}
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseReader(t *testing.T) {
+	assert := assert.New(t)
+
+	r, err := core.NewSparseReader([]core.Extent{
+		{Offset: 6, Data: []byte("ef")},
+		{Offset: 2, Data: []byte("abc")},
+	}, '-')
+	assert.NoError(err)
+
+	size, err := r.Seek(0, io.SeekEnd)
+	assert.NoError(err)
+	assert.Equal(int64(8), size)
+	assert.Equal([]core.Range{{Start: 0, End: 1}, {Start: 5, End: 5}}, r.Holes())
+
+	var matrix = []struct {
+		offset   int64
+		n        int
+		expected string
+	}{
+		{0, 8, "--abc-ef"},
+		{3, 3, "bc-"},
+		{5, 10, "-ef"},
+		{8, 1, ""},
+	}
+
+	for _, test := range matrix {
+		_, err := r.Seek(test.offset, io.SeekStart)
+		assert.NoError(err)
+		buf := make([]byte, test.n)
+		n, _ := r.Read(buf)
+		assert.Equal(test.expected, string(buf[:n]), test.offset)
+	}
+
+	_, err = core.NewSparseReader([]core.Extent{
+		{Offset: 0, Data: []byte("abc")},
+		{Offset: 2, Data: []byte("d")},
+	}, 0)
+	assert.Error(err)
+}
+
+func TestEditorBuffer_Holes(t *testing.T) {
+	assert := assert.New(t)
+
+	// aa----bb----cc
+	r, err := core.NewSparseReader([]core.Extent{
+		{Offset: 0, Data: []byte("aa")},
+		{Offset: 6, Data: []byte("bb")},
+		{Offset: 12, Data: []byte("cc")},
+	}, 0xff)
+	assert.NoError(err)
+
+	var matrix = []struct {
+		change   core.Change
+		expected []core.Range
+	}{
+		// Overwriting part of a hole fills it
+		{core.Change{Position: 3, Removed: 1, Data: []byte("x")}, []core.Range{{2, 2}, {4, 5}, {8, 11}}},
+		// Inserting splits a hole and moves the ones after it
+		{core.Change{Position: 3, Data: []byte("xy")}, []core.Range{{2, 2}, {5, 7}, {10, 13}}},
+		// Removing bytes between holes joins them
+		{core.Change{Position: 6, Removed: 2}, []core.Range{{2, 9}}},
+		// Removing a whole hole
+		{core.Change{Position: 2, Removed: 4}, []core.Range{{4, 7}}},
+		// Replacing bytes across a hole
+		{core.Change{Position: 1, Removed: 6, Data: []byte("xyz")}, []core.Range{{5, 8}}},
+	}
+
+	for _, test := range matrix {
+		eb := core.NewEditorBuffer("", r)
+		assert.Equal([]core.Range{{2, 5}, {8, 11}}, eb.Holes())
+
+		eb.PreviewChange(&test.change)
+		assert.Equal(test.expected, eb.Holes(), test.change)
+		eb.CommitChange()
+		assert.Equal(test.expected, eb.Holes(), test.change)
+		eb.Undo()
+	}
+
+	assert.True(core.IsHole([]core.Range{{2, 5}, {8, 11}}, 5))
+	assert.False(core.IsHole([]core.Range{{2, 5}, {8, 11}}, 6))
+	assert.False(core.IsHole(nil, 0))
+	assert.Equal([]core.Range{{0, 1}, {6, 7}, {12, 13}}, core.Extents(14, []core.Range{{2, 5}, {8, 11}}))
+	assert.Equal([]core.Range{{0, 1}, {6, 6}}, core.Extents(7, []core.Range{{2, 5}, {8, 11}}))
+	assert.Nil(core.NewEditorBuffer("", bytes.NewReader([]byte("aa"))).Holes())
+}
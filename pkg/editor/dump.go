@@ -0,0 +1,226 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hizkifw/gex/internal/display"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// parseOffset parses an offset or length given on the command line. Negative
+// values count from the end of a file of the given size.
+func parseOffset(s string, size int64) (int64, error) {
+	neg := strings.HasPrefix(s, "-")
+	v, err := util.ParseNumber(strings.TrimPrefix(s, "-"), 10)
+	if err != nil {
+		return 0, err
+	}
+	if neg {
+		return util.Max(size-int64(v), 0), nil
+	}
+	return int64(v), nil
+}
+
+// openInput opens the file to read, or stdin if the name is "-" or empty.
+// Stdin is read into memory so that it can be seeked.
+func openInput(name string) (io.ReaderAt, int64, error) {
+	if name == "" || name == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, 0, err
+		}
+		return bytes.NewReader(b), int64(len(b)), nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, st.Size(), nil
+}
+
+// cName converts a file name to a C identifier the way xxd -i does.
+func cName(name string) string {
+	var sb strings.Builder
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		sb.WriteString("__")
+	}
+	for _, c := range name {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// dumpPlain writes the bytes as continuous hex, cols bytes per line.
+func dumpPlain(w io.Writer, r io.Reader, cols int) error {
+	buf := make([]byte, cols)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			fmt.Fprintf(w, "%x\n", buf[:n])
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// dumpInclude writes the bytes as a C array, cols bytes per line. If name is
+// empty, only the array elements are written.
+func dumpInclude(w io.Writer, r io.Reader, name string, cols int) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if name != "" {
+		fmt.Fprintf(w, "unsigned char %s[] = {\n", name)
+	}
+	for i := 0; i < len(b); i += cols {
+		line := make([]string, 0, cols)
+		for _, c := range b[i:util.Min(i+cols, len(b))] {
+			line = append(line, fmt.Sprintf("0x%02x", c))
+		}
+		sep := ","
+		if i+cols >= len(b) {
+			sep = ""
+		}
+		fmt.Fprintf(w, "  %s%s\n", strings.Join(line, ", "), sep)
+	}
+	if name != "" {
+		fmt.Fprintf(w, "};\nunsigned int %s_len = %d;\n", name, len(b))
+	}
+	return nil
+}
+
+// runDump implements the dump subcommand and returns the exit code.
+func runDump(args []string) int {
+	fset := flag.NewFlagSet("dump", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: gex dump [options] [file]")
+		fmt.Fprintln(fset.Output(), "")
+		fmt.Fprintln(fset.Output(), "Print a hex dump of the file, or stdin if no file is given.")
+		fmt.Fprintln(fset.Output(), "")
+		fset.PrintDefaults()
+	}
+	seek := fset.String("s", "0", "start at `offset` (negative counts from the end)")
+	length := fset.String("l", "", "stop after `len` bytes")
+	cols := fset.Int("c", 0, "number of `cols` per line (default 16, 30 with -p, 12 with -i)")
+	group := fset.Int("g", 1, "number of bytes per `group`")
+	little := fset.Bool("e", false, "show groups as little endian")
+	format := fset.String("f", "hex", "cell `format`: hex, oct, bin, dec, sdec or float")
+	color := fset.String("color", "none", "colour `mode`: none, auto, truecolor, 256 or 16")
+	plain := fset.Bool("p", false, "output plain hex without addresses or text")
+	include := fset.Bool("i", false, "output a C include file")
+	if err := fset.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fset.NArg() > 1 {
+		fset.Usage()
+		return 2
+	}
+
+	name := fset.Arg(0)
+	f, size, err := openInput(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading file: %v\n", err)
+		return 1
+	}
+
+	start, err := parseOffset(*seek, size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid offset: %v\n", err)
+		return 2
+	}
+	start = util.Min(start, size)
+	n := size - start
+	if *length != "" {
+		l, err := parseOffset(*length, n)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid length: %v\n", err)
+			return 2
+		}
+		n = util.Min(l, n)
+	}
+	section := io.NewSectionReader(f, start, n)
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	if *plain {
+		if *cols == 0 {
+			*cols = 30
+		}
+		err = dumpPlain(out, section, *cols)
+	} else if *include {
+		if *cols == 0 {
+			*cols = 12
+		}
+		varName := ""
+		if name != "" && name != "-" {
+			varName = cName(filepath.Base(name))
+		}
+		err = dumpInclude(out, section, varName, *cols)
+	} else {
+		if *cols == 0 {
+			*cols = 16
+		}
+		err = dumpHexView(out, section, name, start, *cols, *group, *little, *format, *color)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// dumpHexView writes the bytes in the layout of the editor's hex view. The
+// addresses start at the given offset.
+func dumpHexView(w io.Writer, r io.ReadSeeker, name string, offset int64, cols, group int, little bool, format, color string) error {
+	m := display.NewModel()
+	endian := "big"
+	if little {
+		endian = "little"
+	}
+
+	opts := [][2]string{
+		{"colors", color},
+		{"colorbytes", strconv.FormatBool(color != "none")},
+		{"group", "1"},
+		{"cols", strconv.Itoa(cols)},
+		{"group", strconv.Itoa(group)},
+		{"endian", endian},
+		{"format", format},
+		{"addrbase", strconv.FormatInt(offset, 10)},
+	}
+	for _, opt := range opts {
+		if err := m.SetOption(opt[0], opt[1]); err != nil {
+			return err
+		}
+	}
+
+	m.LoadReader(name, r)
+	return m.Dump(w)
+}
@@ -0,0 +1,145 @@
+// Package editor runs gex. cmd/gex is a thin wrapper around Main, so a program
+// that compiles in plugins from the plugin package can provide the same
+// command line by importing its plugins and calling Main from its own main
+// function.
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hizkifw/gex/doc"
+	"github.com/hizkifw/gex/internal/display"
+)
+
+// Step is a command or a script to run before, or instead of, the editor.
+type Step struct {
+	// Command is a command line, such as "set cols=8".
+	Command string
+
+	// Script is the path of a command or Lua script, or "-" for stdin. It is
+	// used when Command is empty.
+	Script string
+}
+
+// Options configures Run.
+type Options struct {
+	// File is the file to open.
+	File string
+
+	// Steps are run in order without starting the editor. If there are none,
+	// the interactive editor is started instead.
+	Steps []Step
+
+	// Config is the configuration file to load. If it is empty, the default
+	// configuration file is loaded if it exists.
+	Config string
+}
+
+// Main parses the command line arguments, without the program name, runs the
+// editor or one of its subcommands, and returns the exit code.
+func Main(args []string) int {
+	if len(args) < 1 {
+		fmt.Println("Usage: gex <filename>")
+		fmt.Println("")
+		fmt.Println("For help, run:")
+		fmt.Println("  gex -h")
+		return 1
+	}
+
+	switch args[0] {
+	case "dump":
+		return runDump(args[1:])
+
+	case "undump":
+		return runUndump(args[1:])
+
+	case "patch":
+		return runPatch(args[1:])
+
+	case "-h", "--help":
+		fname := "help"
+		if len(args) > 1 {
+			fname = args[1]
+		}
+		fname += ".md"
+		b, err := doc.Docs.ReadFile(fname)
+		if err != nil {
+			fmt.Printf("Error loading help file: %v", err)
+			return 1
+		}
+		os.Stdout.Write(b)
+		return 0
+
+	case "--list-help":
+		files, err := doc.Docs.ReadDir(".")
+		if err != nil {
+			fmt.Printf("Error loading help files: %v", err)
+			return 1
+		}
+		fmt.Println("Availble help files:")
+		for _, f := range files {
+			if strings.HasSuffix(f.Name(), ".md") {
+				fmt.Printf("- %s\n", f.Name()[:len(f.Name())-3])
+			}
+		}
+		return 0
+	}
+
+	file, steps, err := parseEditorArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if file == "" {
+		fmt.Println("Usage: gex [-c <command>]... [-s <script>]... <filename>")
+		return 1
+	}
+	return Run(Options{File: file, Steps: steps})
+}
+
+// Run opens the file and either runs the steps or starts the interactive
+// editor. It returns the exit code.
+func Run(opts Options) int {
+	m := display.NewModel()
+	if len(opts.Steps) > 0 {
+		m.OnStatus = printStatus
+	}
+
+	// Load the user's configuration file, if any
+	rc := opts.Config
+	if rc == "" {
+		rc, _ = display.DefaultConfigPath()
+	}
+	if rc != "" {
+		if err := m.LoadConfig(rc); err != nil && (opts.Config != "" || !errors.Is(err, fs.ErrNotExist)) {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		}
+	}
+
+	if err := m.LoadFile(opts.File); err != nil {
+		fmt.Printf("Error loading file: %v", err)
+		return 1
+	}
+
+	// Run the commands without starting the editor
+	if len(opts.Steps) > 0 {
+		code := runHeadless(&m, opts.Steps)
+		m.Close()
+		return code
+	}
+
+	final, err := tea.NewProgram(m).Run()
+	if fm, ok := final.(display.Model); ok {
+		fm.Close()
+	}
+	if err != nil {
+		fmt.Printf("Alas, there's been an error: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package editor_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/editor"
+	"github.com/hizkifw/gex/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "gexrc")
+	assert.NoError(os.WriteFile(config, []byte("set cols=8\n"), 0644))
+	script := filepath.Join(dir, "script")
+	assert.NoError(os.WriteFile(script, []byte("goto 2\nfill 58\nw\n"), 0644))
+
+	// Plugins registered by the program are available to the steps
+	plugin.RegisterCommand(plugin.Command{Name: "editortest", Run: func(ed plugin.Editor, args []string) error {
+		return ed.SetOption("cols", "4")
+	}})
+
+	var matrix = []struct {
+		steps   []editor.Step
+		expCode int
+		expData string
+	}{
+		{[]editor.Step{{Command: "fill 00"}, {Command: "wq"}}, 0, "\x00bcdef"},
+		{[]editor.Step{{Script: script}}, 0, "abXdef"},
+		{[]editor.Step{{Command: "editortest"}, {Command: "set cols"}}, 0, "abcdef"},
+		{[]editor.Step{{Command: "fill 00"}, {Command: "q!"}, {Command: "w"}}, 0, "abcdef"},
+		{[]editor.Step{{Command: "nosuchcommand"}, {Command: "fill 00"}, {Command: "w"}}, 1, "abcdef"},
+		{[]editor.Step{{Script: filepath.Join(dir, "missing")}}, 1, "abcdef"},
+	}
+
+	for i, test := range matrix {
+		file := filepath.Join(dir, "test.bin")
+		assert.NoError(os.WriteFile(file, []byte("abcdef"), 0644))
+		assert.Equal(test.expCode, editor.Run(editor.Options{File: file, Steps: test.steps, Config: config}), i)
+		data, err := os.ReadFile(file)
+		assert.NoError(err, i)
+		assert.Equal(test.expData, string(data), i)
+	}
+}
+
+func TestMainArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(1, editor.Main(nil))
+	assert.Equal(2, editor.Main([]string{"-x", "file"}))
+	assert.Equal(2, editor.Main([]string{"-c"}))
+	assert.Equal(2, editor.Main([]string{"a", "b"}))
+}
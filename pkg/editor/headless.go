@@ -0,0 +1,92 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hizkifw/gex/internal/display"
+)
+
+// parseEditorArgs parses the arguments of the editor when no subcommand is
+// given. It returns the file to open and the -c and -s options in the order
+// they were given.
+func parseEditorArgs(args []string) (string, []Step, error) {
+	file := ""
+	steps := make([]Step, 0)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-c" || arg == "-s":
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("option %s needs an argument", arg)
+			}
+			i++
+			if arg == "-c" {
+				steps = append(steps, Step{Command: args[i]})
+			} else {
+				steps = append(steps, Step{Script: args[i]})
+			}
+
+		case arg == "--":
+			if i+1 < len(args) {
+				if file != "" || i+2 < len(args) {
+					return "", nil, fmt.Errorf("only one file can be opened")
+				}
+				file = args[i+1]
+			}
+			return file, steps, nil
+
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return "", nil, fmt.Errorf("unknown option: %s", arg)
+
+		default:
+			if file != "" {
+				return "", nil, fmt.Errorf("only one file can be opened")
+			}
+			file = arg
+		}
+	}
+	return file, steps, nil
+}
+
+// runHeadless executes the steps without starting the editor and returns the
+// exit code. Status messages are printed to stdout, and errors to stderr.
+// Execution stops at the first error or when a command quits the editor.
+func runHeadless(m *display.Model, steps []Step) int {
+	for _, step := range steps {
+		if m.Quitting() {
+			break
+		}
+
+		var err error
+		if step.Script == "" {
+			if err = m.ExecCommand(step.Command); err != nil {
+				err = fmt.Errorf("%s: %w", step.Command, err)
+			}
+		} else if step.Script == "-" {
+			err = m.ExecScript(os.Stdin, "stdin")
+		} else {
+			var f *os.File
+			if f, err = os.Open(step.Script); err == nil {
+				err = m.ExecScript(f, step.Script)
+				f.Close()
+			}
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// printStatus prints a status message when running headless.
+func printStatus(text string, isError bool) {
+	if isError {
+		fmt.Fprintln(os.Stderr, text)
+	} else {
+		fmt.Println(text)
+	}
+}
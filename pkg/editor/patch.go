@@ -0,0 +1,204 @@
+package editor
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// readRange reads up to n bytes at the offset of the buffer.
+func readRange(eb *core.EditorBuffer, offset, n int64) ([]byte, error) {
+	rs := eb.ReadSeeker()
+	if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	read, err := io.ReadFull(rs, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:read], err
+}
+
+// writeDiffLines writes the bytes as hex, 16 bytes per line, with each line
+// prefixed by the sign and the offset of its first byte.
+func writeDiffLines(w io.Writer, sign string, offset int64, data []byte) {
+	for i := 0; i < len(data); i += 16 {
+		line := data[i:util.Min(i+16, len(data))]
+		hex := make([]string, len(line))
+		for j, c := range line {
+			hex[j] = fmt.Sprintf("%02x", c)
+		}
+		fmt.Fprintf(w, "%s%08x: %s\n", sign, offset+int64(i), strings.Join(hex, " "))
+	}
+}
+
+// writePatchDiff writes the bytes removed and added by a patch, before it is
+// applied to the buffer.
+func writePatchDiff(w io.Writer, eb *core.EditorBuffer, p core.Patch) error {
+	chg := p.Change()
+	old, err := readRange(eb, chg.Position, chg.Removed)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "@@ %s 0x%x", p.Op, p.Offset)
+	if len(old) > 0 {
+		fmt.Fprintf(w, " -%d", len(old))
+	}
+	if len(chg.Data) > 0 {
+		fmt.Fprintf(w, " +%d", len(chg.Data))
+	}
+	fmt.Fprintln(w, " @@")
+	writeDiffLines(w, "-", chg.Position, old)
+	writeDiffLines(w, "+", chg.Position, chg.Data)
+	return nil
+}
+
+// runPatch implements the patch subcommand and returns the exit code.
+func runPatch(args []string) int {
+	patches := make([]core.Patch, 0)
+	addPatch := func(op string) func(string) error {
+		return func(arg string) error {
+			p, err := core.ParsePatch(op, arg)
+			if err != nil {
+				return err
+			}
+			patches = append(patches, p)
+			return nil
+		}
+	}
+
+	fset := flag.NewFlagSet("patch", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: gex patch <file> [options]")
+		fmt.Fprintln(fset.Output(), "")
+		fmt.Fprintln(fset.Output(), "Apply edits to a file without starting the editor. Edits are applied")
+		fmt.Fprintln(fset.Output(), "in the order given, and offsets refer to the file as left by the")
+		fmt.Fprintln(fset.Output(), "previous edits. Checksums described by the file's .gex rules file are")
+		fmt.Fprintln(fset.Output(), "updated after the edits.")
+		fmt.Fprintln(fset.Output(), "")
+		fset.PrintDefaults()
+	}
+	fset.Func("set", "overwrite bytes, given as `offset=hex`", addPatch("set"))
+	fset.Func("insert", "insert bytes, given as `offset=hex`", addPatch("insert"))
+	fset.Func("delete", "delete bytes, given as `offset:length`", addPatch("delete"))
+	fset.Func("script", "read edits from a patch script `file`", func(name string) error {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		ps, err := core.ParsePatchScript(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		patches = append(patches, ps...)
+		return nil
+	})
+	dryRun := fset.Bool("dry-run", false, "print the changes instead of saving them")
+	noChecksums := fset.Bool("no-checksums", false, "do not update the checksums in the file's .gex rules file")
+	output := fset.String("o", "", "write the result to `file` instead of the input file")
+
+	// Allow the file name to come before or between the options
+	files := make([]string, 0, 1)
+	for {
+		if err := fset.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return 0
+			}
+			return 2
+		}
+		if fset.NArg() == 0 {
+			break
+		}
+		files = append(files, fset.Arg(0))
+		args = fset.Args()[1:]
+	}
+	if len(files) != 1 || len(patches) == 0 {
+		fset.Usage()
+		return 2
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading file: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+	eb := core.NewEditorBuffer(files[0], f)
+
+	var rules []core.ChecksumRule
+	if !*noChecksums {
+		if rules, err = core.LoadChecksumRules(files[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading checksum rules: %v\n", err)
+			return 1
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	for _, p := range patches {
+		if *dryRun {
+			if err := writePatchDiff(out, eb, p); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				return 1
+			}
+		}
+		if err := eb.ApplyPatch(p); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	// Store the correct checksums after all other edits
+	for _, r := range rules {
+		chg, err := eb.ChecksumChange(r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", r, err)
+			return 1
+		}
+		if chg == nil {
+			continue
+		}
+
+		p := core.Patch{Op: core.PatchSet, Offset: chg.Position, Data: chg.Data}
+		if *dryRun {
+			fmt.Fprintf(out, "# %s\n", r)
+			if err := writePatchDiff(out, eb, p); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				return 1
+			}
+		}
+		if err := eb.ApplyPatch(p); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	if *dryRun {
+		st, err := f.Stat()
+		if err == nil && st.Size() != eb.Size() {
+			fmt.Fprintf(out, "size %d -> %d\n", st.Size(), eb.Size())
+		}
+		return 0
+	}
+
+	if *output != "" && *output != files[0] {
+		_, err = eb.WriteToFile(*output)
+	} else {
+		_, err = eb.Save("")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving file: %v\n", err)
+		return 1
+	}
+	return 0
+}
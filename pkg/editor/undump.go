@@ -0,0 +1,137 @@
+package editor
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// writeChunks writes the chunks of a hex dump to a stream, filling the gaps
+// between them with zeros. The chunks must not overlap.
+func writeChunks(w io.Writer, chunks []util.DumpChunk) error {
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].Offset < chunks[j].Offset })
+
+	pos := int64(0)
+	for _, c := range chunks {
+		if c.Offset < pos {
+			return fmt.Errorf("offset %x overlaps previous data", c.Offset)
+		}
+		if _, err := w.Write(make([]byte, c.Offset-pos)); err != nil {
+			return err
+		}
+		if _, err := w.Write(c.Data); err != nil {
+			return err
+		}
+		pos = c.Offset + int64(len(c.Data))
+	}
+	return nil
+}
+
+// runUndump implements the undump subcommand and returns the exit code.
+func runUndump(args []string) int {
+	fset := flag.NewFlagSet("undump", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: gex undump [options] [infile [outfile]]")
+		fmt.Fprintln(fset.Output(), "")
+		fmt.Fprintln(fset.Output(), "Convert a hex dump back into binary. The dump is read from stdin if")
+		fmt.Fprintln(fset.Output(), "no infile is given. If an outfile is given, the bytes are written at")
+		fmt.Fprintln(fset.Output(), "their offsets without truncating the file, so a dump can patch it.")
+		fmt.Fprintln(fset.Output(), "")
+		fset.PrintDefaults()
+	}
+	cols := fset.Int("c", 16, "number of `cols` per line, if it cannot be detected from the offsets")
+	seek := fset.String("s", "0", "add `offset` to the offsets in the dump (can be negative)")
+	plain := fset.Bool("p", false, "read plain hex without offsets, like xxd -p")
+	if err := fset.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fset.NArg() > 2 {
+		fset.Usage()
+		return 2
+	}
+
+	in := io.Reader(os.Stdin)
+	if name := fset.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var chunks []util.DumpChunk
+	if *plain {
+		b, err := util.ParsePlainHex(in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		chunks = []util.DumpChunk{{Data: b}}
+	} else {
+		var err error
+		if chunks, err = util.ParseDump(in, *cols); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	// Shift the offsets
+	v, err := util.ParseNumber(strings.TrimPrefix(*seek, "-"), 10)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid offset: %v\n", err)
+		return 2
+	}
+	shift := int64(v)
+	if strings.HasPrefix(*seek, "-") {
+		shift = -shift
+	}
+	for i := range chunks {
+		chunks[i].Offset += shift
+		if chunks[i].Offset < 0 {
+			fmt.Fprintf(os.Stderr, "Error: offset %x is negative after shifting\n", chunks[i].Offset-shift)
+			return 1
+		}
+	}
+
+	if out := fset.Arg(1); out != "" && out != "-" {
+		f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening output: %v\n", err)
+			return 1
+		}
+		for _, c := range chunks {
+			if _, err := f.WriteAt(c.Data, c.Offset); err != nil {
+				f.Close()
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				return 1
+			}
+		}
+		if err := f.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := writeChunks(w, chunks); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,193 @@
+// Package hexfile reads and writes bytes in text formats used by flash tools
+// and source code, such as Intel HEX, Motorola S-records and C arrays.
+//
+// Formats that carry addresses, such as Intel HEX, can hold several separate
+// segments of data. The other formats hold a single segment at address 0.
+package hexfile
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Segment is a contiguous block of data at an address.
+type Segment struct {
+	Address uint64
+	Data    []byte
+}
+
+// End returns the address after the last byte of the segment.
+func (s Segment) End() uint64 {
+	return s.Address + uint64(len(s.Data))
+}
+
+// Format is a format that data can be read from and written to.
+type Format struct {
+	// Name is the name of the format, such as "ihex".
+	Name string
+
+	// Extensions are the file extensions of the format, including the dot.
+	Extensions []string
+
+	// Read reads the segments in a file, sorted by address.
+	Read func(r io.Reader) ([]Segment, error)
+
+	// Write writes the segments to a file. The name is used by formats that
+	// name their data, such as the variable name of a C array.
+	Write func(w io.Writer, name string, segments []Segment) error
+
+	// ReadLayout and WriteLayout read and write files whose records can be
+	// written back the way they were read, or are nil for other formats.
+	ReadLayout  func(r io.Reader) ([]Segment, *Layout, error)
+	WriteLayout func(w io.Writer, segments []Segment, layout *Layout) error
+}
+
+// formats are the supported formats.
+var formats = []*Format{
+	{
+		Name: "ihex", Extensions: []string{".hex", ".ihex", ".ihx"},
+		Read: ReadIntelHex, Write: WriteIntelHex,
+		ReadLayout: ReadIntelHexLayout, WriteLayout: WriteIntelHexLayout,
+	},
+	{
+		Name: "srec", Extensions: []string{".srec", ".s19", ".s28", ".s37", ".mot"},
+		Read: ReadSRec, Write: WriteSRec,
+		ReadLayout: ReadSRecLayout, WriteLayout: WriteSRecLayout,
+	},
+	{Name: "c", Extensions: []string{".c", ".h"}, Read: flatReader(ReadCArray), Write: flatWriter(WriteCArray)},
+	{Name: "go", Extensions: []string{".go"}, Read: flatReader(ReadGoSlice), Write: flatWriter(WriteGoSlice)},
+	{Name: "python", Extensions: []string{".py"}, Read: flatReader(ReadPythonBytes), Write: flatWriter(WritePythonBytes)},
+	{Name: "base64", Extensions: []string{".b64", ".base64"}, Read: flatReader(ReadBase64), Write: flatWriter(WriteBase64)},
+}
+
+// Lookup returns the format with the given name.
+func Lookup(name string) (*Format, error) {
+	for _, f := range formats {
+		if strings.EqualFold(f.Name, name) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown format: %s", name)
+}
+
+// FormatForFile returns the format of a file going by its extension, or nil
+// if the extension is not known.
+func FormatForFile(path string) *Format {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range formats {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the supported formats.
+func Names() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// flatReader adapts a reader of a format without addresses.
+func flatReader(read func(r io.Reader) ([]byte, error)) func(r io.Reader) ([]Segment, error) {
+	return func(r io.Reader) ([]Segment, error) {
+		data, err := read(r)
+		if err != nil {
+			return nil, err
+		}
+		return []Segment{{Data: data}}, nil
+	}
+}
+
+// flatWriter adapts a writer of a format without addresses. The segments are
+// written as one block, and must therefore be contiguous.
+func flatWriter(write func(w io.Writer, name string, data []byte) error) func(w io.Writer, name string, segments []Segment) error {
+	return func(w io.Writer, name string, segments []Segment) error {
+		data := make([]byte, 0)
+		for i, s := range segments {
+			if i > 0 && s.Address != segments[i-1].End() {
+				return fmt.Errorf("data at 0x%x is not contiguous", s.Address)
+			}
+			data = append(data, s.Data...)
+		}
+		return write(w, name, data)
+	}
+}
+
+// mergeSegments sorts the segments by address and joins those that are
+// adjacent. Overlapping segments are an error.
+func mergeSegments(segments []Segment) ([]Segment, error) {
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].Address < segments[j].Address })
+
+	merged := make([]Segment, 0, len(segments))
+	for _, s := range segments {
+		if len(s.Data) == 0 {
+			continue
+		}
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if s.Address < last.End() {
+				return nil, fmt.Errorf("overlapping data at 0x%x", s.Address)
+			}
+			if s.Address == last.End() {
+				last.Data = append(last.Data, s.Data...)
+				continue
+			}
+		}
+		merged = append(merged, Segment{Address: s.Address, Data: append([]byte{}, s.Data...)})
+	}
+	return merged, nil
+}
+
+// Flatten joins the segments into a single block starting at the address of
+// the first segment, filling the gaps between them with the fill byte. The
+// segments must be sorted by address and must not overlap.
+func Flatten(segments []Segment, fill byte) (uint64, []byte) {
+	if len(segments) == 0 {
+		return 0, []byte{}
+	}
+
+	base := segments[0].Address
+	data := make([]byte, segments[len(segments)-1].End()-base)
+	for i := range data {
+		data[i] = fill
+	}
+	for _, s := range segments {
+		copy(data[s.Address-base:], s.Data)
+	}
+	return base, data
+}
+
+// Identifier turns a file name into a name usable as a variable, such as
+// "firmware_bin" for "out/firmware.bin.c".
+func Identifier(path string) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+
+	var sb strings.Builder
+	for i, c := range base {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+			sb.WriteRune(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(c)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "data"
+	}
+	return sb.String()
+}
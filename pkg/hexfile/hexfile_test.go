@@ -0,0 +1,104 @@
+package hexfile_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/hexfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	data := append(bytes.Repeat([]byte("hello, gex! "), 8), 0x00, 0xff, '\'', '"', '\\', '\n')
+	for _, name := range hexfile.Names() {
+		f, err := hexfile.Lookup(name)
+		if !assert.NoError(err, name) {
+			continue
+		}
+		assert.Equal(name, f.Name)
+
+		var buf bytes.Buffer
+		assert.NoError(f.Write(&buf, "data", []hexfile.Segment{{Data: data}}), name)
+		segments, err := f.Read(&buf)
+		assert.NoError(err, name)
+		assert.Equal([]hexfile.Segment{{Data: data}}, segments, name)
+	}
+
+	_, err := hexfile.Lookup("elf")
+	assert.Error(err)
+}
+
+func TestFormatNotContiguous(t *testing.T) {
+	assert := assert.New(t)
+
+	segments := []hexfile.Segment{{Address: 0, Data: []byte{1}}, {Address: 2, Data: []byte{2}}}
+	for _, name := range []string{"c", "go", "python", "base64"} {
+		f, _ := hexfile.Lookup(name)
+		assert.Error(f.Write(&bytes.Buffer{}, "data", segments), name)
+	}
+}
+
+func TestFormatForFile(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		path     string
+		expected string
+	}{
+		{"fw.hex", "ihex"},
+		{"out/FW.IHX", "ihex"},
+		{"fw.s19", "srec"},
+		{"fw.srec", "srec"},
+		{"blob.h", "c"},
+		{"blob.go", "go"},
+		{"blob.py", "python"},
+		{"blob.b64", "base64"},
+		{"blob.bin", ""},
+		{"blob", ""},
+	}
+
+	for _, test := range matrix {
+		f := hexfile.FormatForFile(test.path)
+		if test.expected == "" {
+			assert.Nil(f, test.path)
+		} else if assert.NotNil(f, test.path) {
+			assert.Equal(test.expected, f.Name, test.path)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	assert := assert.New(t)
+
+	base, data := hexfile.Flatten([]hexfile.Segment{
+		{Address: 0x100, Data: []byte{1, 2}},
+		{Address: 0x104, Data: []byte{3}},
+	}, 0xff)
+	assert.Equal(uint64(0x100), base)
+	assert.Equal([]byte{1, 2, 0xff, 0xff, 3}, data)
+
+	base, data = hexfile.Flatten(nil, 0xff)
+	assert.Equal(uint64(0), base)
+	assert.Equal([]byte{}, data)
+}
+
+func TestIdentifier(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected string
+	}{
+		{"out/firmware.bin.c", "firmware_bin"},
+		{"blob.h", "blob"},
+		{"my-data.py", "my_data"},
+		{"1st.go", "_1st"},
+		{".c", "data"},
+	}
+
+	for _, test := range matrix {
+		assert.Equal(test.expected, hexfile.Identifier(test.inp), test.inp)
+	}
+}
@@ -0,0 +1,168 @@
+package hexfile
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// Intel HEX record types
+const (
+	ihexData                   = 0x00
+	ihexEndOfFile              = 0x01
+	ihexExtendedSegmentAddress = 0x02
+	ihexStartSegmentAddress    = 0x03
+	ihexExtendedLinearAddress  = 0x04
+	ihexStartLinearAddress     = 0x05
+)
+
+// recordSize is the number of data bytes written per record.
+const recordSize = 16
+
+// ReadIntelHex reads the data records of an Intel HEX file. Reading stops at
+// the end of file record.
+func ReadIntelHex(r io.Reader) ([]Segment, error) {
+	segments, _, err := ReadIntelHexLayout(r)
+	return segments, err
+}
+
+// ReadIntelHexLayout reads the data records of an Intel HEX file, and how
+// they are laid out.
+func ReadIntelHexLayout(r io.Reader) ([]Segment, *Layout, error) {
+	scanner, crlf, err := scanLines(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		segments []Segment
+		base     uint64
+	)
+	layout := &Layout{CRLF: crlf}
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		rec, err := parseIntelHexRecord(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		typ, addr, data := rec[3], uint64(rec[1])<<8|uint64(rec[2]), rec[4:len(rec)-1]
+		switch typ {
+		case ihexData:
+			if len(data) == 0 {
+				continue
+			}
+			segments = append(segments, Segment{Address: base + addr, Data: data})
+			layout.Records = append(layout.Records, Span{Address: base + addr, Length: len(data)})
+			layout.RecordSize = util.Max(layout.RecordSize, len(data))
+		case ihexEndOfFile:
+			if layout.RecordSize == 0 {
+				layout.RecordSize = recordSize
+			}
+			segments, err := mergeSegments(segments)
+			return segments, layout, err
+		case ihexExtendedSegmentAddress, ihexExtendedLinearAddress:
+			if len(data) != 2 {
+				return nil, nil, fmt.Errorf("line %d: address record has %d bytes", lineNo, len(data))
+			}
+			base = uint64(data[0])<<8 | uint64(data[1])
+			if typ == ihexExtendedSegmentAddress {
+				base <<= 4
+				layout.SegmentAddresses = true
+			} else {
+				base <<= 16
+			}
+		case ihexStartSegmentAddress, ihexStartLinearAddress:
+			// Entry points have no place in the buffer, but are kept
+			layout.Trailer = append(layout.Trailer, line)
+		default:
+			return nil, nil, fmt.Errorf("line %d: unknown record type %02x", lineNo, typ)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return nil, nil, fmt.Errorf("missing end of file record")
+}
+
+// parseIntelHexRecord decodes a record and checks its length and checksum.
+// It returns the bytes of the record, starting with the data length.
+func parseIntelHexRecord(line string) ([]byte, error) {
+	if line[0] != ':' {
+		return nil, fmt.Errorf("record does not start with ':'")
+	}
+	rec, err := hex.DecodeString(line[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid record: %w", err)
+	}
+	if len(rec) < 5 || len(rec) != int(rec[0])+5 {
+		return nil, fmt.Errorf("record length does not match")
+	}
+
+	var sum byte
+	for _, b := range rec {
+		sum += b
+	}
+	if sum != 0 {
+		return nil, fmt.Errorf("bad checksum")
+	}
+	return rec, nil
+}
+
+// WriteIntelHex writes the segments as Intel HEX, using extended linear
+// address records for addresses above 64 KiB.
+func WriteIntelHex(w io.Writer, name string, segments []Segment) error {
+	return WriteIntelHexLayout(w, segments, &Layout{RecordSize: recordSize})
+}
+
+// WriteIntelHexLayout writes the segments as Intel HEX, following the layout
+// of the file they were read from.
+func WriteIntelHexLayout(w io.Writer, segments []Segment, layout *Layout) error {
+	if n := len(segments); n > 0 && segments[n-1].End() > 1<<32 {
+		return fmt.Errorf("address 0x%x does not fit in 32 bits", segments[n-1].End()-1)
+	}
+
+	lw := newLineWriter(w, layout)
+	var base uint64
+	for _, rec := range layout.records(segments) {
+		for len(rec.Data) > 0 {
+			if rec.Address&^0xffff != base {
+				base = rec.Address &^ 0xffff
+				if layout.SegmentAddresses && base < 1<<20 {
+					lw.line(formatIntelHexRecord(ihexExtendedSegmentAddress, 0, []byte{byte(base >> 12), 0}))
+				} else {
+					lw.line(formatIntelHexRecord(ihexExtendedLinearAddress, 0, []byte{byte(base >> 24), byte(base >> 16)}))
+				}
+			}
+
+			// Split records that cross a 64 KiB boundary
+			n := util.Min(uint64(len(rec.Data)), base+0x10000-rec.Address)
+			lw.line(formatIntelHexRecord(ihexData, uint16(rec.Address), rec.Data[:n]))
+			rec.Address, rec.Data = rec.Address+n, rec.Data[n:]
+		}
+	}
+	for _, line := range layout.Trailer {
+		lw.line(line)
+	}
+	lw.line(formatIntelHexRecord(ihexEndOfFile, 0, nil))
+	return lw.Flush()
+}
+
+// formatIntelHexRecord formats a single record.
+func formatIntelHexRecord(typ byte, addr uint16, data []byte) string {
+	rec := append([]byte{byte(len(data)), byte(addr >> 8), byte(addr), typ}, data...)
+	var sum byte
+	for _, b := range rec {
+		sum += b
+	}
+	rec = append(rec, -sum)
+	return ":" + strings.ToUpper(hex.EncodeToString(rec))
+}
@@ -0,0 +1,109 @@
+package hexfile_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/hexfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadIntelHex(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected []hexfile.Segment
+		expError bool
+	}{
+		{
+			":10010000214601360121470136007EFE09D2190140\n:00000001FF\n",
+			[]hexfile.Segment{{Address: 0x100, Data: []byte{
+				0x21, 0x46, 0x01, 0x36, 0x01, 0x21, 0x47, 0x01, 0x36, 0x00, 0x7e, 0xfe, 0x09, 0xd2, 0x19, 0x01,
+			}}},
+			false,
+		},
+		{
+			// Adjacent records are joined, and gaps split segments
+			":020000000102FB\r\n:020002000304F5\r\n:01001000AA45\r\n:00000001FF\r\n",
+			[]hexfile.Segment{
+				{Address: 0x00, Data: []byte{1, 2, 3, 4}},
+				{Address: 0x10, Data: []byte{0xaa}},
+			},
+			false,
+		},
+		{
+			// Extended linear and segment addresses
+			":020000040800F2\n:0100000011EE\n:020000021000EC\n:0100000022DD\n:0400000508000000EF\n:00000001FF\n",
+			[]hexfile.Segment{
+				{Address: 0x10000, Data: []byte{0x22}},
+				{Address: 0x08000000, Data: []byte{0x11}},
+			},
+			false,
+		},
+		{
+			// Records after the end of file are ignored
+			":0100000011EE\n:00000001FF\ngarbage\n",
+			[]hexfile.Segment{{Address: 0, Data: []byte{0x11}}},
+			false,
+		},
+		{":0100000011EF\n:00000001FF\n", nil, true},
+		{":0200000011EE\n:00000001FF\n", nil, true},
+		{"0100000011EE\n:00000001FF\n", nil, true},
+		{":01000000ZZEE\n:00000001FF\n", nil, true},
+		{":0100000011EE\n", nil, true},
+		{":0100000011EE\n:0100000022DD\n:00000001FF\n", nil, true},
+		{":0100000611E8\n:00000001FF\n", nil, true},
+	}
+
+	for _, test := range matrix {
+		segments, err := hexfile.ReadIntelHex(strings.NewReader(test.inp))
+		if test.expError {
+			assert.Error(err, test.inp)
+			continue
+		}
+		assert.NoError(err, test.inp)
+		assert.Equal(test.expected, segments, test.inp)
+	}
+}
+
+func TestWriteIntelHex(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      []hexfile.Segment
+		expected string
+		expError bool
+	}{
+		{
+			[]hexfile.Segment{{Address: 0x100, Data: []byte{
+				0x21, 0x46, 0x01, 0x36, 0x01, 0x21, 0x47, 0x01, 0x36, 0x00, 0x7e, 0xfe, 0x09, 0xd2, 0x19, 0x01,
+			}}},
+			":10010000214601360121470136007EFE09D2190140\n:00000001FF\n",
+			false,
+		},
+		{
+			// Records are aligned, and switch to extended addresses
+			[]hexfile.Segment{
+				{Address: 0xfffe, Data: []byte{1, 2, 3}},
+				{Address: 0x08000000, Data: []byte{0x11}},
+			},
+			":02FFFE000102FE\n:020000040001F9\n:0100000003FC\n:020000040800F2\n:0100000011EE\n:00000001FF\n",
+			false,
+		},
+		{nil, ":00000001FF\n", false},
+		{[]hexfile.Segment{{Address: 0xffffffff, Data: []byte{1, 2}}}, "", true},
+	}
+
+	for _, test := range matrix {
+		var buf bytes.Buffer
+		err := hexfile.WriteIntelHex(&buf, "", test.inp)
+		if test.expError {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.expected, buf.String())
+	}
+}
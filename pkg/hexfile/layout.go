@@ -0,0 +1,144 @@
+package hexfile
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// Span is the address range of a record.
+type Span struct {
+	Address uint64
+	Length  int
+}
+
+// End returns the address after the last byte of the span.
+func (s Span) End() uint64 {
+	return s.Address + uint64(s.Length)
+}
+
+// Layout describes how the data of an Intel HEX or S-record file was split
+// into records, so that the file can be written back the same way after its
+// data has been edited.
+type Layout struct {
+	// Records are the data records, in the order of the file.
+	Records []Span
+
+	// RecordSize is the length of the longest data record, which is used for
+	// data outside the records.
+	RecordSize int
+
+	// CRLF is whether lines end with "\r\n".
+	CRLF bool
+
+	// Trailer holds the records written before the end of file record of an
+	// Intel HEX file, such as its start address.
+	Trailer []string
+
+	// SegmentAddresses is whether an Intel HEX file uses extended segment
+	// addresses rather than extended linear addresses.
+	SegmentAddresses bool
+
+	// Header is the header record of an S-record file.
+	Header string
+
+	// AddressSize is the smallest number of address bytes of the data records
+	// of an S-record file.
+	AddressSize int
+
+	// Count is whether an S-record file has a record count.
+	Count bool
+
+	// Entry is the address in the termination record of an S-record file.
+	Entry uint64
+}
+
+// records splits the segments into records. Data within a record of the
+// layout is written in a record at the same address and of the same length,
+// as far as it is still there. The rest is written in new records that are
+// aligned to the record size. If the records of the layout are in address
+// order, the new records are put in order with them.
+func (l *Layout) records(segments []Segment) []Segment {
+	size := util.Max(l.RecordSize, 1)
+
+	out := make([]Segment, 0, len(l.Records))
+	for _, span := range l.Records {
+		out = append(out, clipSegments(segments, span.Address, span.End())...)
+	}
+
+	spans := append([]Span{}, l.Records...)
+	sorted := sort.SliceIsSorted(spans, func(i, j int) bool { return spans[i].Address < spans[j].Address })
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Address < spans[j].Address })
+
+	for _, s := range segments {
+		addNew := func(start, end uint64) {
+			for addr := start; addr < end; {
+				n := util.Min(uint64(size)-addr%uint64(size), end-addr)
+				out = append(out, Segment{Address: addr, Data: s.Data[addr-s.Address : addr-s.Address+n]})
+				addr += n
+			}
+		}
+
+		// Walk the spans that overlap the segment, adding the data between
+		i := sort.Search(len(spans), func(i int) bool { return spans[i].End() > s.Address })
+		pos := s.Address
+		for ; i < len(spans) && spans[i].Address < s.End(); i++ {
+			if spans[i].Address > pos {
+				addNew(pos, spans[i].Address)
+			}
+			pos = util.Max(pos, spans[i].End())
+		}
+		if pos < s.End() {
+			addNew(pos, s.End())
+		}
+	}
+
+	if sorted {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	}
+	return out
+}
+
+// clipSegments returns the parts of the segments between the start and end
+// addresses. The segments must be sorted and must not overlap.
+func clipSegments(segments []Segment, start, end uint64) []Segment {
+	out := make([]Segment, 0, 1)
+	i := sort.Search(len(segments), func(i int) bool { return segments[i].End() > start })
+	for ; i < len(segments) && segments[i].Address < end; i++ {
+		s := segments[i]
+		from, to := util.Max(start, s.Address), util.Min(end, s.End())
+		out = append(out, Segment{Address: from, Data: s.Data[from-s.Address : to-s.Address]})
+	}
+	return out
+}
+
+// lineWriter writes the lines of a file with the line ending of the layout.
+type lineWriter struct {
+	*bufio.Writer
+	eol string
+}
+
+func newLineWriter(w io.Writer, l *Layout) *lineWriter {
+	eol := "\n"
+	if l.CRLF {
+		eol = "\r\n"
+	}
+	return &lineWriter{bufio.NewWriter(w), eol}
+}
+
+func (w *lineWriter) line(s string) {
+	w.WriteString(s + w.eol)
+}
+
+// scanLines reads the file and returns a scanner over its lines, and whether
+// its lines end with "\r\n".
+func scanLines(r io.Reader) (*bufio.Scanner, bool, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, false, err
+	}
+	return bufio.NewScanner(bytes.NewReader(data)), bytes.Contains(data, []byte("\r\n")), nil
+}
@@ -0,0 +1,84 @@
+package hexfile_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/hexfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntelHexLayoutRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []string{
+		// Unaligned records of different lengths, with a start address
+		":0300030001020AED\r\n:080006000304050607080900C8\r\n:04000005080000ED02\r\n:00000001FF\r\n",
+		// Extended segment addresses
+		":020000021000EC\n:040000001122334452\n:00000001FF\n",
+		// Records out of address order
+		":020010000304E7\n:020000000102FB\n:00000001FF\n",
+	}
+
+	for _, inp := range matrix {
+		segments, layout, err := hexfile.ReadIntelHexLayout(strings.NewReader(inp))
+		if !assert.NoError(err, inp) {
+			continue
+		}
+		var buf bytes.Buffer
+		assert.NoError(hexfile.WriteIntelHexLayout(&buf, segments, layout), inp)
+		assert.Equal(inp, buf.String())
+	}
+}
+
+func TestIntelHexLayoutEdited(t *testing.T) {
+	assert := assert.New(t)
+
+	inp := ":0400000001020304F2\n:04000800090A0B0CCA\n:00000001FF\n"
+	_, layout, err := hexfile.ReadIntelHexLayout(strings.NewReader(inp))
+	assert.NoError(err)
+
+	// The first record lost a byte, and the gap was filled in part
+	segments := []hexfile.Segment{
+		{Address: 0x0, Data: []byte{0x01, 0x02, 0x03}},
+		{Address: 0x5, Data: []byte{0xaa, 0xbb, 0xcc, 0x09, 0x0a, 0x0b, 0x0c, 0xdd}},
+	}
+	var buf bytes.Buffer
+	assert.NoError(hexfile.WriteIntelHexLayout(&buf, segments, layout))
+	assert.Equal(
+		":03000000010203F7\n"+
+			":03000500AABBCCC7\n"+
+			":04000800090A0B0CCA\n"+
+			":01000C00DD16\n"+
+			":00000001FF\n",
+		buf.String())
+}
+
+func TestSRecLayoutRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []string{
+		// 24 bit addresses and 8 byte records, with an entry point
+		"S00600004844521B\r\nS20C0000000001020304050607D7\r\nS2070000080A0B0CCF\r\nS804000008F3\r\n",
+		// Without a count or header, but with 32 bit addresses
+		"S30900000000AABBCCDDE8\nS70500000000FA\n",
+	}
+
+	for _, inp := range matrix {
+		segments, layout, err := hexfile.ReadSRecLayout(strings.NewReader(inp))
+		if !assert.NoError(err, inp) {
+			continue
+		}
+		var buf bytes.Buffer
+		assert.NoError(hexfile.WriteSRecLayout(&buf, segments, layout), inp)
+		assert.Equal(inp, buf.String())
+	}
+
+	// The address size grows when the data no longer fits
+	_, layout, err := hexfile.ReadSRecLayout(strings.NewReader("S1040000FFFC\nS9030000FC\n"))
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.NoError(hexfile.WriteSRecLayout(&buf, []hexfile.Segment{{Address: 0x10000, Data: []byte{0xff}}}, layout))
+	assert.Equal("S205010000FFFA\nS804000000FB\n", buf.String())
+}
@@ -0,0 +1,243 @@
+package hexfile
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// bytesPerLine is the number of bytes per line of source code.
+const bytesPerLine = 12
+
+// WriteCArray writes the data as a C array and its length, in the style of
+// xxd -i.
+func WriteCArray(w io.Writer, name string, data []byte) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "unsigned char %s[] = {\n", name)
+	writeByteList(bw, "  ", data)
+	fmt.Fprintf(bw, "};\nunsigned int %s_len = %d;\n", name, len(data))
+	return bw.Flush()
+}
+
+// WriteGoSlice writes the data as a Go byte slice.
+func WriteGoSlice(w io.Writer, name string, data []byte) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "var %s = []byte{\n", name)
+	writeByteList(bw, "\t", data)
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
+
+// writeByteList writes the bytes as hexadecimal literals separated by
+// commas, with a trailing comma.
+func writeByteList(w *bufio.Writer, indent string, data []byte) {
+	for i, b := range data {
+		switch {
+		case i%bytesPerLine == 0:
+			w.WriteString(indent)
+		default:
+			w.WriteString(" ")
+		}
+		fmt.Fprintf(w, "0x%02x,", b)
+		if i%bytesPerLine == bytesPerLine-1 || i == len(data)-1 {
+			w.WriteString("\n")
+		}
+	}
+}
+
+// cComment matches C and Go comments.
+var cComment = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+
+// ReadCArray reads the values between the first pair of braces in C source
+// code. Values may be written in decimal, octal or hexadecimal, or as
+// character literals.
+func ReadCArray(r io.Reader) ([]byte, error) {
+	return readByteList(r)
+}
+
+// ReadGoSlice reads the values between the first pair of braces in Go source
+// code.
+func ReadGoSlice(r io.Reader) ([]byte, error) {
+	return readByteList(r)
+}
+
+// readByteList reads the comma separated values between the first pair of
+// braces.
+func readByteList(r io.Reader) ([]byte, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := cComment.ReplaceAllString(string(src), "")
+
+	start := strings.IndexByte(text, '{')
+	end := strings.IndexByte(text, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no array found")
+	}
+
+	data := make([]byte, 0)
+	for _, field := range strings.Split(text[start+1:end], ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		b, err := parseByteLiteral(field)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b)
+	}
+	return data, nil
+}
+
+// parseByteLiteral parses an integer or character literal that fits in a
+// byte, ignoring casts and integer suffixes.
+func parseByteLiteral(s string) (byte, error) {
+	if i := strings.LastIndexByte(s, ')'); i >= 0 {
+		s = strings.TrimSpace(s[i+1:])
+	}
+	if len(s) >= 3 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		c, _, tail, err := strconv.UnquoteChar(s[1:len(s)-1], '\'')
+		if err != nil || tail != "" || c > 0xff {
+			return 0, fmt.Errorf("invalid character: %s", s)
+		}
+		return byte(c), nil
+	}
+
+	n, err := strconv.ParseUint(strings.TrimRight(s, "uUlL"), 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte: %s", s)
+	}
+	return byte(n), nil
+}
+
+// WritePythonBytes writes the data as a Python bytes literal.
+func WritePythonBytes(w io.Writer, name string, data []byte) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s = (\n", name)
+	for i := 0; i < len(data) || i == 0; i += 2 * bytesPerLine {
+		bw.WriteString("    b'")
+		for _, b := range data[i:util.Min(i+2*bytesPerLine, len(data))] {
+			fmt.Fprintf(bw, "\\x%02x", b)
+		}
+		bw.WriteString("'\n")
+	}
+	bw.WriteString(")\n")
+	return bw.Flush()
+}
+
+// pythonBytes matches a Python bytes literal.
+var pythonBytes = regexp.MustCompile(`(?i)\b(?:b|br|rb)('(?:[^'\\\n]|\\.)*'|"(?:[^"\\\n]|\\.)*")`)
+
+// ReadPythonBytes reads and joins every bytes literal in Python source code.
+func ReadPythonBytes(r io.Reader) ([]byte, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := pythonBytes.FindAllStringSubmatchIndex(string(src), -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no bytes literal found")
+	}
+	data := make([]byte, 0)
+	for _, m := range matches {
+		prefix := strings.ToLower(string(src[m[0]:m[2]]))
+		body := string(src[m[2]+1 : m[3]-1])
+		if strings.Contains(prefix, "r") {
+			data = append(data, body...)
+			continue
+		}
+		b, err := unescapePython(body)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+// unescapePython decodes the escape sequences of a Python bytes literal.
+func unescapePython(s string) ([]byte, error) {
+	data := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			data = append(data, s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return nil, fmt.Errorf("unterminated escape sequence")
+		}
+
+		switch c := s[i]; c {
+		case 'x':
+			if i+3 > len(s) {
+				return nil, fmt.Errorf("invalid escape sequence: \\x%s", s[i+1:])
+			}
+			n, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid escape sequence: \\x%s", s[i+1:i+3])
+			}
+			data = append(data, byte(n))
+			i += 2
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i + 1
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(s[i:j], 8, 16)
+			data = append(data, byte(n))
+			i = j - 1
+		case 'n':
+			data = append(data, '\n')
+		case 'r':
+			data = append(data, '\r')
+		case 't':
+			data = append(data, '\t')
+		case 'a':
+			data = append(data, '\a')
+		case 'b':
+			data = append(data, '\b')
+		case 'f':
+			data = append(data, '\f')
+		case 'v':
+			data = append(data, '\v')
+		case '\\', '\'', '"':
+			data = append(data, c)
+		case '\n':
+			// Line continuation
+		default:
+			// Unknown escapes are kept as they are
+			data = append(data, '\\', c)
+		}
+	}
+	return data, nil
+}
+
+// WriteBase64 writes the data as base64 in lines of 76 characters.
+func WriteBase64(w io.Writer, name string, data []byte) error {
+	text := base64.StdEncoding.EncodeToString(data)
+	bw := bufio.NewWriter(w)
+	for len(text) > 76 {
+		bw.WriteString(text[:76] + "\n")
+		text = text[76:]
+	}
+	bw.WriteString(text + "\n")
+	return bw.Flush()
+}
+
+// ReadBase64 reads base64 data, ignoring whitespace.
+func ReadBase64(r io.Reader) ([]byte, error) {
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(text)), ""))
+}
@@ -0,0 +1,122 @@
+package hexfile_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/hexfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSource(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []byte("\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c")
+	var matrix = []struct {
+		write    func(w *bytes.Buffer) error
+		expected string
+	}{
+		{
+			func(w *bytes.Buffer) error { return hexfile.WriteCArray(w, "blob", data) },
+			"unsigned char blob[] = {\n" +
+				"  0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b,\n" +
+				"  0x0c,\n" +
+				"};\nunsigned int blob_len = 13;\n",
+		},
+		{
+			func(w *bytes.Buffer) error { return hexfile.WriteGoSlice(w, "blob", data) },
+			"var blob = []byte{\n" +
+				"\t0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b,\n" +
+				"\t0x0c,\n" +
+				"}\n",
+		},
+		{
+			func(w *bytes.Buffer) error { return hexfile.WritePythonBytes(w, "blob", data[:3]) },
+			"blob = (\n    b'\\x00\\x01\\x02'\n)\n",
+		},
+		{
+			func(w *bytes.Buffer) error { return hexfile.WritePythonBytes(w, "blob", nil) },
+			"blob = (\n    b''\n)\n",
+		},
+		{
+			func(w *bytes.Buffer) error { return hexfile.WriteBase64(w, "", bytes.Repeat([]byte{0}, 60)) },
+			strings.Repeat("A", 76) + "\n" + strings.Repeat("A", 4) + "\n",
+		},
+	}
+
+	for _, test := range matrix {
+		var buf bytes.Buffer
+		assert.NoError(test.write(&buf))
+		assert.Equal(test.expected, buf.String())
+	}
+}
+
+func TestReadCArray(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected []byte
+		expError bool
+	}{
+		{"unsigned char x[] = {\n  0x00, 0x7f,\n  0xFF\n};\nunsigned int x_len = 3;\n", []byte{0, 0x7f, 0xff}, false},
+		{"static const uint8_t x[4] = { 1, 010, 'a', '\\n' }; // four", []byte{1, 8, 'a', '\n'}, false},
+		{"/* { 9 } */ char x[] = {(char)0x41u, /* skip */ 66};", []byte{'A', 'B'}, false},
+		{"var x = []byte{\n\t0x01, // one\n\t0x02,\n}\n", []byte{1, 2}, false},
+		{"char x[] = {};", []byte{}, false},
+		{"char x[] = {0x100};", nil, true},
+		{"char x[] = {y};", nil, true},
+		{"char x = 0;", nil, true},
+	}
+
+	for _, test := range matrix {
+		data, err := hexfile.ReadCArray(strings.NewReader(test.inp))
+		if test.expError {
+			assert.Error(err, test.inp)
+			continue
+		}
+		assert.NoError(err, test.inp)
+		assert.Equal(test.expected, data, test.inp)
+	}
+}
+
+func TestReadPythonBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected string
+		expError bool
+	}{
+		{"x = b'\\x00\\xffab'\n", "\x00\xffab", false},
+		{"x = (\n    b'\\x01'\n    B\"\\x02\\n\"\n)\n", "\x01\x02\n", false},
+		{"x = b'\\'\\\\\\101\\0'", "'\\A\x00", false},
+		{"x = rb'\\x00'", "\\x00", false},
+		{"x = b'\\q'", "\\q", false},
+		{"x = b'\\x4'", "", true},
+		{"x = b'\\xzz'", "", true},
+		{"x = 'text'", "", true},
+	}
+
+	for _, test := range matrix {
+		data, err := hexfile.ReadPythonBytes(strings.NewReader(test.inp))
+		if test.expError {
+			assert.Error(err, test.inp)
+			continue
+		}
+		assert.NoError(err, test.inp)
+		assert.Equal([]byte(test.expected), data, test.inp)
+	}
+}
+
+func TestReadBase64(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := hexfile.ReadBase64(strings.NewReader("aGVs\nbG8=\n"))
+	assert.NoError(err)
+	assert.Equal([]byte("hello"), data)
+
+	_, err = hexfile.ReadBase64(strings.NewReader("!!!"))
+	assert.Error(err)
+}
@@ -0,0 +1,176 @@
+package hexfile
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// srecAddressSize is the number of address bytes of each S-record type.
+var srecAddressSize = map[byte]int{
+	'0': 2, '1': 2, '2': 3, '3': 4, '5': 2, '6': 3, '7': 4, '8': 3, '9': 2,
+}
+
+// ReadSRec reads the data records of a Motorola S-record file. Reading stops
+// at the termination record.
+func ReadSRec(r io.Reader) ([]Segment, error) {
+	segments, _, err := ReadSRecLayout(r)
+	return segments, err
+}
+
+// ReadSRecLayout reads the data records of a Motorola S-record file, and how
+// they are laid out.
+func ReadSRecLayout(r io.Reader) ([]Segment, *Layout, error) {
+	scanner, crlf, err := scanLines(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var segments []Segment
+	layout := &Layout{CRLF: crlf, AddressSize: 4}
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		typ, addr, data, err := parseSRecord(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		switch typ {
+		case '0':
+			layout.Header = line
+		case '1', '2', '3':
+			if len(data) == 0 {
+				continue
+			}
+			segments = append(segments, Segment{Address: addr, Data: data})
+			layout.Records = append(layout.Records, Span{Address: addr, Length: len(data)})
+			layout.RecordSize = util.Max(layout.RecordSize, len(data))
+			layout.AddressSize = util.Min(layout.AddressSize, srecAddressSize[typ])
+		case '5', '6':
+			layout.Count = true
+		case '7', '8', '9':
+			layout.Entry = addr
+		}
+		if typ >= '7' {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// The termination record is optional in practice
+	if layout.RecordSize == 0 {
+		layout.RecordSize = recordSize
+	}
+	segments, err = mergeSegments(segments)
+	return segments, layout, err
+}
+
+// parseSRecord decodes a record and checks its length and checksum.
+func parseSRecord(line string) (byte, uint64, []byte, error) {
+	if len(line) < 4 || line[0] != 'S' {
+		return 0, 0, nil, fmt.Errorf("record does not start with 'S'")
+	}
+	typ := line[1]
+	size, ok := srecAddressSize[typ]
+	if !ok {
+		return 0, 0, nil, fmt.Errorf("unknown record type S%c", typ)
+	}
+	rec, err := hex.DecodeString(line[2:])
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid record: %w", err)
+	}
+	if len(rec) < size+2 || len(rec) != int(rec[0])+1 {
+		return 0, 0, nil, fmt.Errorf("record length does not match")
+	}
+
+	var sum byte
+	for _, b := range rec {
+		sum += b
+	}
+	if sum != 0xff {
+		return 0, 0, nil, fmt.Errorf("bad checksum")
+	}
+
+	var addr uint64
+	for _, b := range rec[1 : 1+size] {
+		addr = addr<<8 | uint64(b)
+	}
+	return typ, addr, rec[1+size : len(rec)-1], nil
+}
+
+// WriteSRec writes the segments as Motorola S-records, with the name in the
+// header record. The address size is the smallest that fits every segment.
+func WriteSRec(w io.Writer, name string, segments []Segment) error {
+	header := []byte(name)
+	if len(header) > 64 {
+		header = header[:64]
+	}
+	return WriteSRecLayout(w, segments, &Layout{
+		RecordSize:  recordSize,
+		Header:      formatSRecord('0', 0, header),
+		AddressSize: 2,
+		Count:       true,
+	})
+}
+
+// WriteSRecLayout writes the segments as Motorola S-records, following the
+// layout of the file they were read from. The address size grows if the
+// segments no longer fit in the layout's.
+func WriteSRecLayout(w io.Writer, segments []Segment, layout *Layout) error {
+	var end uint64
+	if len(segments) > 0 {
+		end = segments[len(segments)-1].End()
+	}
+	if end > 1<<32 {
+		return fmt.Errorf("address 0x%x does not fit in 32 bits", end-1)
+	}
+	size := util.Max(layout.AddressSize, 2)
+	for size < 4 && end > 1<<(8*size) {
+		size++
+	}
+	data, term := byte('0'+size-1), byte('9'-size+2)
+
+	lw := newLineWriter(w, layout)
+	if layout.Header != "" {
+		lw.line(layout.Header)
+	}
+	records := layout.records(segments)
+	for _, rec := range records {
+		lw.line(formatSRecord(data, rec.Address, rec.Data))
+	}
+	if layout.Count {
+		if len(records) <= 0xffff {
+			lw.line(formatSRecord('5', uint64(len(records)), nil))
+		} else if len(records) <= 0xffffff {
+			lw.line(formatSRecord('6', uint64(len(records)), nil))
+		}
+	}
+	lw.line(formatSRecord(term, layout.Entry, nil))
+	return lw.Flush()
+}
+
+// formatSRecord formats a single record.
+func formatSRecord(typ byte, addr uint64, data []byte) string {
+	size := srecAddressSize[typ]
+	rec := []byte{byte(size + len(data) + 1)}
+	for i := size - 1; i >= 0; i-- {
+		rec = append(rec, byte(addr>>(8*i)))
+	}
+	rec = append(rec, data...)
+
+	var sum byte
+	for _, b := range rec {
+		sum += b
+	}
+	rec = append(rec, ^sum)
+	return fmt.Sprintf("S%c%s", typ, strings.ToUpper(hex.EncodeToString(rec)))
+}
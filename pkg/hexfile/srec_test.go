@@ -0,0 +1,89 @@
+package hexfile_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/hexfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadSRec(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected []hexfile.Segment
+		expError bool
+	}{
+		{
+			"S00F000068656C6C6F202020202000003C\nS1137AF00A0A0D0000000000000000000000000061\nS5030001FB\nS9030000FC\n",
+			[]hexfile.Segment{{Address: 0x7af0, Data: append([]byte{0x0a, 0x0a, 0x0d}, make([]byte, 13)...)}},
+			false,
+		},
+		{
+			// 24 and 32 bit addresses, without a termination record
+			"S2050100001EDB\r\nS3060800000011E0\r\n",
+			[]hexfile.Segment{
+				{Address: 0x010000, Data: []byte{0x1e}},
+				{Address: 0x08000000, Data: []byte{0x11}},
+			},
+			false,
+		},
+		{"S1040000FFFC\nS9030000FC\n", []hexfile.Segment{{Address: 0, Data: []byte{0xff}}}, false},
+		{"S1040000FFFD\n", nil, true},
+		{"S1050000FFFC\n", nil, true},
+		{"X1040000FFFC\n", nil, true},
+		{"S4040000FFFC\n", nil, true},
+		{"S1040000FFFC\nS1040000FFFC\n", nil, true},
+	}
+
+	for _, test := range matrix {
+		segments, err := hexfile.ReadSRec(strings.NewReader(test.inp))
+		if test.expError {
+			assert.Error(err, test.inp)
+			continue
+		}
+		assert.NoError(err, test.inp)
+		assert.Equal(test.expected, segments, test.inp)
+	}
+}
+
+func TestWriteSRec(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      []hexfile.Segment
+		expected string
+		expError bool
+	}{
+		{
+			[]hexfile.Segment{{Address: 0x7af0, Data: append([]byte{0x0a, 0x0a, 0x0d}, make([]byte, 13)...)}},
+			"S0050000686929\nS1137AF00A0A0D0000000000000000000000000061\nS5030001FB\nS9030000FC\n",
+			false,
+		},
+		{
+			[]hexfile.Segment{{Address: 0x010000, Data: []byte{0x1e}}},
+			"S0050000686929\nS2050100001EDB\nS5030001FB\nS804000000FB\n",
+			false,
+		},
+		{
+			[]hexfile.Segment{{Address: 0x08000000, Data: []byte{0x11}}},
+			"S0050000686929\nS3060800000011E0\nS5030001FB\nS70500000000FA\n",
+			false,
+		},
+		{[]hexfile.Segment{{Address: 0xffffffff, Data: []byte{1, 2}}}, "", true},
+	}
+
+	for _, test := range matrix {
+		var buf bytes.Buffer
+		err := hexfile.WriteSRec(&buf, "hi", test.inp)
+		if test.expError {
+			assert.Error(err)
+			continue
+		}
+		assert.NoError(err)
+		assert.Equal(test.expected, buf.String())
+	}
+}
@@ -0,0 +1,279 @@
+// Package plugin lets other packages extend gex with commands, inspector rows,
+// highlighted regions and panels. Plugins register themselves with Default,
+// usually from an init function, and are compiled in by importing their
+// package from a main package that calls editor.Main, as cmd/gex does. The
+// built-in commands, inspectors and panels are registered the same way, so a
+// plugin can replace them by using their names.
+package plugin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/hizkifw/gex/pkg/core"
+	"github.com/hizkifw/gex/pkg/util"
+)
+
+// Editor is the interface through which plugins access the editor.
+type Editor interface {
+	// Buffer returns the buffer being edited. Changes made to it through
+	// PreviewChange and CommitChange can be undone by the user.
+	Buffer() *core.EditorBuffer
+
+	// SetCursor moves the cursor, keeping it inside the buffer.
+	SetCursor(pos int64)
+
+	// Option returns the value of an option as shown by the set command.
+	Option(name string) (string, error)
+
+	// SetOption sets the value of an option.
+	SetOption(name, value string) error
+
+	// ExecCommand runs a command line, such as "set cols=8".
+	ExecCommand(line string) error
+
+	// Prompt asks the user for a line of text. The callback is called with
+	// the text after the current command has finished.
+	Prompt(label string, callback func(ed Editor, input string) error)
+
+	// Print shows a message in the status bar.
+	Print(text string)
+}
+
+// CommandFunc runs a command. As with os.Args, args[0] is the name the
+// command was called by, followed by its arguments. A returned error is shown
+// in the status bar.
+type CommandFunc func(ed Editor, args []string) error
+
+// Command is a command that can be typed after ':'.
+type Command struct {
+	// Name is the canonical name of the command.
+	Name string
+
+	// Aliases are alternative names that run the same command.
+	Aliases []string
+
+	// Usage is a short description of the arguments, such as "goto <address>".
+	Usage string
+
+	// Run runs the command.
+	Run CommandFunc
+}
+
+// InspectorFunc returns inspector rows for the bytes at the cursor. buf can be
+// shorter than the rows need near the end of the buffer.
+type InspectorFunc func(buf []byte, byteOrder binary.ByteOrder) []util.Row
+
+// RegionFunc returns regions to highlight between start and end, which is the
+// part of the buffer that is visible.
+type RegionFunc func(ed Editor, start, end int64) []core.Region
+
+// Panel is a window shown to the right of the hex view.
+type Panel struct {
+	// Name is the title of the panel.
+	Name string
+
+	// Status returns a short text shown next to the title, or nil for none.
+	Status func(ed Editor) string
+
+	// Render returns the contents of the panel, which should fit in the
+	// given height. Returning an empty string hides the panel.
+	Render func(ed Editor, width, height int) string
+}
+
+// Registry holds registered commands, inspectors, region providers and
+// panels.
+type Registry struct {
+	commands   []*Command
+	inspectors []namedInspector
+	regions    []namedRegions
+	panels     []*Panel
+}
+
+type namedInspector struct {
+	name string
+	fn   InspectorFunc
+}
+
+type namedRegions struct {
+	name string
+	fn   RegionFunc
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry used by the editor. Its commands, inspectors and
+// panels take precedence over built-in ones of the same name, and its regions
+// are added to the built-in ones.
+var Default = NewRegistry()
+
+// RegisterCommand registers a command. A command with the same name replaces
+// the previous one.
+func (r *Registry) RegisterCommand(cmd Command) error {
+	if cmd.Name == "" || cmd.Run == nil {
+		return fmt.Errorf("command must have a name and a Run function")
+	}
+
+	for i, c := range r.commands {
+		if c.Name == cmd.Name {
+			r.commands[i] = &cmd
+			return nil
+		}
+	}
+	r.commands = append(r.commands, &cmd)
+	return nil
+}
+
+// Command returns the command with the given name or alias, or nil if there
+// is no such command. Names take precedence over aliases.
+func (r *Registry) Command(name string) *Command {
+	for _, c := range r.commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	for _, c := range r.commands {
+		for _, alias := range c.Aliases {
+			if alias == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// Commands returns the registered commands sorted by name.
+func (r *Registry) Commands() []Command {
+	cmds := make([]Command, len(r.commands))
+	for i, c := range r.commands {
+		cmds[i] = *c
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// RegisterInspector registers a function that adds inspector rows. An
+// inspector with the same name replaces the previous one.
+func (r *Registry) RegisterInspector(name string, fn InspectorFunc) {
+	for i, insp := range r.inspectors {
+		if insp.name == name {
+			r.inspectors[i].fn = fn
+			return
+		}
+	}
+	r.inspectors = append(r.inspectors, namedInspector{name, fn})
+}
+
+// Inspector returns the inspector with the given name, or nil if there is no
+// such inspector.
+func (r *Registry) Inspector(name string) InspectorFunc {
+	for _, insp := range r.inspectors {
+		if insp.name == name {
+			return insp.fn
+		}
+	}
+	return nil
+}
+
+// InspectorNames returns the names of the registered inspectors in the order
+// they were registered.
+func (r *Registry) InspectorNames() []string {
+	names := make([]string, len(r.inspectors))
+	for i, insp := range r.inspectors {
+		names[i] = insp.name
+	}
+	return names
+}
+
+// Inspect returns the rows of all registered inspectors, in the order they
+// were registered.
+func (r *Registry) Inspect(buf []byte, byteOrder binary.ByteOrder) []util.Row {
+	rows := make([]util.Row, 0)
+	for _, insp := range r.inspectors {
+		rows = append(rows, insp.fn(buf, byteOrder)...)
+	}
+	return rows
+}
+
+// RegisterRegions registers a function that adds highlighted regions. A
+// function with the same name replaces the previous one.
+func (r *Registry) RegisterRegions(name string, fn RegionFunc) {
+	for i, reg := range r.regions {
+		if reg.name == name {
+			r.regions[i].fn = fn
+			return
+		}
+	}
+	r.regions = append(r.regions, namedRegions{name, fn})
+}
+
+// Regions returns the regions of all registered region functions between
+// start and end.
+func (r *Registry) Regions(ed Editor, start, end int64) []core.Region {
+	regions := make([]core.Region, 0)
+	for _, reg := range r.regions {
+		regions = append(regions, reg.fn(ed, start, end)...)
+	}
+	return regions
+}
+
+// RegisterPanel registers a panel. A panel with the same name replaces the
+// previous one.
+func (r *Registry) RegisterPanel(panel Panel) error {
+	if panel.Name == "" || panel.Render == nil {
+		return fmt.Errorf("panel must have a name and a Render function")
+	}
+
+	for i, p := range r.panels {
+		if p.Name == panel.Name {
+			r.panels[i] = &panel
+			return nil
+		}
+	}
+	r.panels = append(r.panels, &panel)
+	return nil
+}
+
+// Panel returns the panel with the given name, or nil if there is no such
+// panel.
+func (r *Registry) Panel(name string) *Panel {
+	for _, p := range r.panels {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Panels returns the registered panels in the order they were registered.
+func (r *Registry) Panels() []Panel {
+	panels := make([]Panel, len(r.panels))
+	for i, p := range r.panels {
+		panels[i] = *p
+	}
+	return panels
+}
+
+// RegisterCommand registers a command with the default registry.
+func RegisterCommand(cmd Command) error {
+	return Default.RegisterCommand(cmd)
+}
+
+// RegisterInspector registers an inspector with the default registry.
+func RegisterInspector(name string, fn InspectorFunc) {
+	Default.RegisterInspector(name, fn)
+}
+
+// RegisterRegions registers a region function with the default registry.
+func RegisterRegions(name string, fn RegionFunc) {
+	Default.RegisterRegions(name, fn)
+}
+
+// RegisterPanel registers a panel with the default registry.
+func RegisterPanel(panel Panel) error {
+	return Default.RegisterPanel(panel)
+}
@@ -0,0 +1,79 @@
+package plugin_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/plugin"
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Command(t *testing.T) {
+	assert := assert.New(t)
+
+	r := plugin.NewRegistry()
+	run := func(ed plugin.Editor, args []string) error { return nil }
+	assert.NoError(r.RegisterCommand(plugin.Command{Name: "write", Aliases: []string{"w"}, Run: run}))
+	assert.NoError(r.RegisterCommand(plugin.Command{Name: "w", Usage: "w [file]", Run: run}))
+	assert.NoError(r.RegisterCommand(plugin.Command{Name: "fill", Run: run}))
+	assert.Error(r.RegisterCommand(plugin.Command{Name: "broken"}))
+
+	// Names take precedence over aliases
+	assert.Equal("w", r.Command("w").Name)
+	assert.Equal("write", r.Command("write").Name)
+	assert.Nil(r.Command("broken"))
+
+	// Registering the same name replaces the command
+	assert.NoError(r.RegisterCommand(plugin.Command{Name: "fill", Usage: "fill <pattern>", Run: run}))
+	assert.Equal("fill <pattern>", r.Command("fill").Usage)
+
+	names := make([]string, 0)
+	for _, c := range r.Commands() {
+		names = append(names, c.Name)
+	}
+	assert.Equal([]string{"fill", "w", "write"}, names)
+}
+
+func TestRegistry_Inspect(t *testing.T) {
+	assert := assert.New(t)
+
+	r := plugin.NewRegistry()
+	row := func(key string) plugin.InspectorFunc {
+		return func(buf []byte, byteOrder binary.ByteOrder) []util.Row {
+			return []util.Row{{Key: key, Val: string(buf)}}
+		}
+	}
+	r.RegisterInspector("a", row("A"))
+	r.RegisterInspector("b", row("B"))
+	r.RegisterInspector("a", row("C"))
+
+	rows := r.Inspect([]byte("x"), binary.LittleEndian)
+	assert.Len(rows, 2)
+	assert.Equal("C", rows[0].Key)
+	assert.Equal("B", rows[1].Key)
+	assert.Equal("x", rows[1].Val)
+
+	assert.Equal([]string{"a", "b"}, r.InspectorNames())
+	assert.Equal("B", r.Inspector("b")(nil, binary.LittleEndian)[0].Key)
+	assert.Nil(r.Inspector("c"))
+}
+
+func TestRegistry_Panel(t *testing.T) {
+	assert := assert.New(t)
+
+	r := plugin.NewRegistry()
+	render := func(text string) func(plugin.Editor, int, int) string {
+		return func(ed plugin.Editor, width, height int) string { return text }
+	}
+	assert.NoError(r.RegisterPanel(plugin.Panel{Name: "One", Render: render("1")}))
+	assert.NoError(r.RegisterPanel(plugin.Panel{Name: "Two", Render: render("2")}))
+	assert.NoError(r.RegisterPanel(plugin.Panel{Name: "One", Render: render("3")}))
+	assert.Error(r.RegisterPanel(plugin.Panel{Name: "Empty"}))
+
+	panels := r.Panels()
+	assert.Len(panels, 2)
+	assert.Equal("3", panels[0].Render(nil, 0, 0))
+	assert.Equal("Two", panels[1].Name)
+	assert.Nil(r.Panel("Empty"))
+}
@@ -0,0 +1,32 @@
+package util
+
+// ByteClass is a category of byte values, used to colour bytes by their kind.
+type ByteClass int
+
+const (
+	ByteClassNone ByteClass = iota
+	ByteClassNull
+	ByteClassFF
+	ByteClassPrintable
+	ByteClassWhitespace
+	ByteClassControl
+	ByteClassHigh
+)
+
+// ClassifyByte returns the class of the byte. Whitespace characters are
+// classified as whitespace rather than printable or control characters.
+func ClassifyByte(b byte) ByteClass {
+	switch {
+	case b == 0x00:
+		return ByteClassNull
+	case b == 0xff:
+		return ByteClassFF
+	case b == ' ' || (b >= '\t' && b <= '\r'):
+		return ByteClassWhitespace
+	case b > ' ' && b < 0x7f:
+		return ByteClassPrintable
+	case b < 0x80:
+		return ByteClassControl
+	}
+	return ByteClassHigh
+}
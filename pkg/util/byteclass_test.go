@@ -0,0 +1,34 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyByte(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      byte
+		expected util.ByteClass
+	}{
+		{0x00, util.ByteClassNull},
+		{0xff, util.ByteClassFF},
+		{' ', util.ByteClassWhitespace},
+		{'\t', util.ByteClassWhitespace},
+		{'\r', util.ByteClassWhitespace},
+		{'A', util.ByteClassPrintable},
+		{'~', util.ByteClassPrintable},
+		{0x01, util.ByteClassControl},
+		{0x1b, util.ByteClassControl},
+		{0x7f, util.ByteClassControl},
+		{0x80, util.ByteClassHigh},
+		{0xfe, util.ByteClassHigh},
+	}
+
+	for _, test := range matrix {
+		assert.Equal(test.expected, util.ClassifyByte(test.inp), "byte %02x", test.inp)
+	}
+}
@@ -0,0 +1,126 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CellFormat is the number format used to display a group of bytes.
+type CellFormat int
+
+const (
+	CellHex CellFormat = iota
+	CellOctal
+	CellBinary
+	CellDecimal
+	CellSigned
+	CellFloat
+)
+
+var cellFormatNames = map[CellFormat]string{
+	CellHex:     "hex",
+	CellOctal:   "oct",
+	CellBinary:  "bin",
+	CellDecimal: "dec",
+	CellSigned:  "sdec",
+	CellFloat:   "float",
+}
+
+func (f CellFormat) String() string {
+	return cellFormatNames[f]
+}
+
+// ParseCellFormat parses the name of a cell format.
+func ParseCellFormat(name string) (CellFormat, error) {
+	for f, n := range cellFormatNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return CellHex, fmt.Errorf("expected one of hex, oct, bin, dec, sdec or float")
+}
+
+// ValidateCellFormat checks that the format can display groups of the given
+// size. Groups must be 1, 2, 4 or 8 bytes long, and floats must be 4 or 8
+// bytes long.
+func ValidateCellFormat(f CellFormat, size int) error {
+	if size != 1 && size != 2 && size != 4 && size != 8 {
+		return fmt.Errorf("group size must be 1, 2, 4 or 8")
+	}
+	if f == CellFloat && size != 4 && size != 8 {
+		return fmt.Errorf("float requires a group size of 4 or 8")
+	}
+	return nil
+}
+
+// CellWidth returns the number of characters needed to display a group of
+// size bytes in the given format.
+func CellWidth(f CellFormat, size int) int {
+	switch f {
+	case CellOctal:
+		return (size*8 + 2) / 3
+	case CellBinary:
+		return size * 8
+	case CellDecimal:
+		return len(strconv.FormatUint(math.MaxUint64>>(64-size*8), 10))
+	case CellSigned:
+		return len(strconv.FormatInt(math.MinInt64>>(64-size*8), 10))
+	case CellFloat:
+		if size == 4 {
+			return 12
+		}
+		return 19
+	}
+	return size * 2
+}
+
+// groupValue reads a group of 1, 2, 4 or 8 bytes as an unsigned integer.
+func groupValue(buf []byte, byteOrder binary.ByteOrder) uint64 {
+	switch len(buf) {
+	case 1:
+		return uint64(buf[0])
+	case 2:
+		return uint64(byteOrder.Uint16(buf))
+	case 4:
+		return uint64(byteOrder.Uint32(buf))
+	}
+	return byteOrder.Uint64(buf)
+}
+
+// FormatCell formats a group of bytes in the given format, padded to the
+// width returned by CellWidth. The length of buf is the group size.
+func FormatCell(buf []byte, f CellFormat, byteOrder binary.ByteOrder) string {
+	size := len(buf)
+	width := CellWidth(f, size)
+	v := groupValue(buf, byteOrder)
+
+	var s string
+	switch f {
+	case CellHex:
+		s = fmt.Sprintf("%0*x", width, v)
+	case CellOctal:
+		s = fmt.Sprintf("%0*o", width, v)
+	case CellBinary:
+		s = fmt.Sprintf("%0*b", width, v)
+	case CellDecimal:
+		s = strconv.FormatUint(v, 10)
+	case CellSigned:
+		// Sign-extend the value
+		shift := 64 - size*8
+		s = strconv.FormatInt(int64(v<<shift)>>shift, 10)
+	case CellFloat:
+		if size == 4 {
+			s = strconv.FormatFloat(float64(math.Float32frombits(uint32(v))), 'g', 6, 32)
+		} else {
+			s = strconv.FormatFloat(math.Float64frombits(v), 'g', 12, 64)
+		}
+	}
+
+	if len(s) < width {
+		s = strings.Repeat(" ", width-len(s)) + s
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package util_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCellWidth(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(2, util.CellWidth(util.CellHex, 1))
+	assert.Equal(16, util.CellWidth(util.CellHex, 8))
+	assert.Equal(3, util.CellWidth(util.CellOctal, 1))
+	assert.Equal(22, util.CellWidth(util.CellOctal, 8))
+	assert.Equal(32, util.CellWidth(util.CellBinary, 4))
+	assert.Equal(3, util.CellWidth(util.CellDecimal, 1))
+	assert.Equal(10, util.CellWidth(util.CellDecimal, 4))
+	assert.Equal(20, util.CellWidth(util.CellDecimal, 8))
+	assert.Equal(4, util.CellWidth(util.CellSigned, 1))
+	assert.Equal(6, util.CellWidth(util.CellSigned, 2))
+	assert.Equal(20, util.CellWidth(util.CellSigned, 8))
+}
+
+func TestFormatCell(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      []byte
+		format   util.CellFormat
+		order    binary.ByteOrder
+		expected string
+	}{
+		{[]byte{0xab}, util.CellHex, binary.BigEndian, "ab"},
+		{[]byte{0x12, 0x34}, util.CellHex, binary.BigEndian, "1234"},
+		{[]byte{0x12, 0x34}, util.CellHex, binary.LittleEndian, "3412"},
+		{[]byte{0x08}, util.CellOctal, binary.BigEndian, "010"},
+		{[]byte{0x05}, util.CellBinary, binary.BigEndian, "00000101"},
+		{[]byte{0x07}, util.CellDecimal, binary.BigEndian, "  7"},
+		{[]byte{0xff, 0xff}, util.CellDecimal, binary.BigEndian, "65535"},
+		{[]byte{0xff}, util.CellSigned, binary.BigEndian, "  -1"},
+		{[]byte{0x00, 0x80}, util.CellSigned, binary.LittleEndian, "-32768"},
+		{[]byte{0x00, 0x00, 0x80, 0x3f}, util.CellFloat, binary.LittleEndian, "           1"},
+		{[]byte{0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18}, util.CellFloat, binary.BigEndian, "      3.14159265359"},
+	}
+
+	for _, test := range matrix {
+		actual := util.FormatCell(test.inp, test.format, test.order)
+		assert.Equal(test.expected, actual)
+		assert.Equal(util.CellWidth(test.format, len(test.inp)), len(actual))
+	}
+}
+
+func TestValidateCellFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(util.ValidateCellFormat(util.CellHex, 1))
+	assert.NoError(util.ValidateCellFormat(util.CellFloat, 8))
+	assert.Error(util.ValidateCellFormat(util.CellHex, 3))
+	assert.Error(util.ValidateCellFormat(util.CellFloat, 2))
+}
@@ -0,0 +1,223 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Charset is a character encoding used to display and type text.
+type Charset struct {
+	// Name is the canonical name of the charset.
+	Name string
+
+	// Align is the size of a code unit. Characters only start at offsets
+	// that are a multiple of Align.
+	Align int
+
+	// decode decodes the character at the start of buf and returns it along
+	// with its size in bytes. It returns utf8.RuneError if buf does not start
+	// with a valid character.
+	decode func(buf []byte) (rune, int)
+
+	// encode encodes a single character, and returns false if the character
+	// cannot be represented in the charset.
+	encode func(r rune) ([]byte, bool)
+}
+
+// Decode decodes the character at the start of buf and returns it along with
+// its size in bytes. If buf does not start with a valid, printable character,
+// it returns utf8.RuneError and a size of 1.
+func (c *Charset) Decode(buf []byte) (rune, int) {
+	if len(buf) == 0 {
+		return utf8.RuneError, 1
+	}
+	r, size := c.decode(buf)
+	if r == utf8.RuneError || size < 1 || !unicode.IsGraphic(r) {
+		return utf8.RuneError, 1
+	}
+	return r, size
+}
+
+// Encode encodes the string in the charset. It returns an error if the string
+// contains a character that cannot be represented in the charset.
+func (c *Charset) Encode(s string) ([]byte, error) {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := c.encode(r)
+		if !ok {
+			return nil, fmt.Errorf("cannot encode %q in %s", r, c.Name)
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+// cp437Glyphs are the glyphs that code page 437 displays for the control
+// characters 0x00-0x1f.
+var cp437Glyphs = []rune(" ☺☻♥♦♣♠•◘○◙♂♀♪♫☼►◄↕‼¶§▬↨↑↓→←∟↔▲▼")
+
+// codePage500 is EBCDIC code page 500, which differs from code page 037 in the
+// placement of a few punctuation characters.
+var codePage500 = map[byte]rune{
+	0x4a: '[', 0x4f: '!', 0x5a: ']', 0x5f: '^',
+	0xb0: '¢', 0xba: '¬', 0xbb: '|',
+}
+
+// charmapCharset creates a single-byte charset from a character map. The
+// overrides take precedence over the character map.
+func charmapCharset(name string, cm *charmap.Charmap, overrides map[byte]rune) *Charset {
+	var decodeTable [256]rune
+	encodeTable := make(map[rune]byte)
+	for i := 255; i >= 0; i-- {
+		r := cm.DecodeByte(byte(i))
+		if o, ok := overrides[byte(i)]; ok {
+			r = o
+		}
+		decodeTable[i] = r
+		encodeTable[r] = byte(i)
+	}
+
+	return &Charset{
+		Name:  name,
+		Align: 1,
+		decode: func(buf []byte) (rune, int) {
+			return decodeTable[buf[0]], 1
+		},
+		encode: func(r rune) ([]byte, bool) {
+			b, ok := encodeTable[r]
+			return []byte{b}, ok
+		},
+	}
+}
+
+// utf16Charset creates a UTF-16 charset with the given byte order.
+func utf16Charset(name string, bigEndian bool) *Charset {
+	unit := func(buf []byte) uint16 {
+		if bigEndian {
+			return uint16(buf[0])<<8 | uint16(buf[1])
+		}
+		return uint16(buf[1])<<8 | uint16(buf[0])
+	}
+	putUnit := func(out []byte, u uint16) []byte {
+		if bigEndian {
+			return append(out, byte(u>>8), byte(u))
+		}
+		return append(out, byte(u), byte(u>>8))
+	}
+
+	return &Charset{
+		Name:  name,
+		Align: 2,
+		decode: func(buf []byte) (rune, int) {
+			if len(buf) < 2 {
+				return utf8.RuneError, 1
+			}
+			r := rune(unit(buf))
+			if !utf16.IsSurrogate(r) {
+				return r, 2
+			}
+			if len(buf) < 4 {
+				return utf8.RuneError, 1
+			}
+			return utf16.DecodeRune(r, rune(unit(buf[2:]))), 4
+		},
+		encode: func(r rune) ([]byte, bool) {
+			out := make([]byte, 0, 4)
+			for _, u := range utf16.Encode([]rune{r}) {
+				out = putUnit(out, u)
+			}
+			return out, r != utf8.RuneError
+		},
+	}
+}
+
+// charsets is the list of supported charsets, keyed by name and alias.
+var charsets = func() map[string]*Charset {
+	ascii := &Charset{
+		Name:  "ascii",
+		Align: 1,
+		decode: func(buf []byte) (rune, int) {
+			if buf[0] >= 0x80 {
+				return utf8.RuneError, 1
+			}
+			return rune(buf[0]), 1
+		},
+		encode: func(r rune) ([]byte, bool) {
+			return []byte{byte(r)}, r < 0x80
+		},
+	}
+
+	cp437Overrides := map[byte]rune{0x7f: '⌂'}
+	for i, r := range cp437Glyphs[1:] {
+		cp437Overrides[byte(i+1)] = r
+	}
+
+	sjis := &Charset{
+		Name:  "shift-jis",
+		Align: 1,
+		decode: func(buf []byte) (rune, int) {
+			size := 1
+			if (buf[0] >= 0x81 && buf[0] <= 0x9f) || (buf[0] >= 0xe0 && buf[0] <= 0xfc) {
+				size = 2
+			}
+			if len(buf) < size {
+				return utf8.RuneError, 1
+			}
+			dec, err := japanese.ShiftJIS.NewDecoder().Bytes(buf[:size])
+			r, n := utf8.DecodeRune(dec)
+			if err != nil || n != len(dec) {
+				return utf8.RuneError, 1
+			}
+			return r, size
+		},
+		encode: func(r rune) ([]byte, bool) {
+			b, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(string(r)))
+			return b, err == nil
+		},
+	}
+
+	utf8Charset := &Charset{
+		Name:   "utf-8",
+		Align:  1,
+		decode: utf8.DecodeRune,
+		encode: func(r rune) ([]byte, bool) {
+			return []byte(string(r)), utf8.ValidRune(r)
+		},
+	}
+
+	list := map[string]*Charset{
+		"ascii":     ascii,
+		"latin1":    charmapCharset("latin1", charmap.ISO8859_1, nil),
+		"cp437":     charmapCharset("cp437", charmap.CodePage437, cp437Overrides),
+		"cp037":     charmapCharset("cp037", charmap.CodePage037, nil),
+		"cp500":     charmapCharset("cp500", charmap.CodePage037, codePage500),
+		"shift-jis": sjis,
+		"utf-8":     utf8Charset,
+		"utf-16le":  utf16Charset("utf-16le", false),
+		"utf-16be":  utf16Charset("utf-16be", true),
+	}
+
+	// Aliases
+	list["iso-8859-1"] = list["latin1"]
+	list["ebcdic"] = list["cp037"]
+	list["sjis"] = list["shift-jis"]
+	list["utf8"] = list["utf-8"]
+	list["utf-16"] = list["utf-16le"]
+
+	return list
+}()
+
+// LookupCharset returns the charset with the given name.
+func LookupCharset(name string) (*Charset, error) {
+	c, ok := charsets[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding: %s", name)
+	}
+	return c, nil
+}
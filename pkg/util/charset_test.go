@@ -0,0 +1,84 @@
+package util_test
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharset_Decode(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		charset string
+		inp     []byte
+		expRune rune
+		expSize int
+	}{
+		{"ascii", []byte("A"), 'A', 1},
+		{"ascii", []byte{0x01}, utf8.RuneError, 1},
+		{"ascii", []byte{0xe9}, utf8.RuneError, 1},
+		{"latin1", []byte{0xe9}, 'é', 1},
+		{"latin1", []byte{0x85}, utf8.RuneError, 1},
+		{"cp437", []byte{0x01}, '☺', 1},
+		{"cp437", []byte{0x7f}, '⌂', 1},
+		{"cp437", []byte{0xdb}, '█', 1},
+		{"cp037", []byte{0xc1}, 'A', 1},
+		{"cp037", []byte{0x4a}, '¢', 1},
+		{"cp500", []byte{0x4a}, '[', 1},
+		{"shift-jis", []byte{0x82, 0xa0}, 'あ', 2},
+		{"shift-jis", []byte{0xb1}, 'ｱ', 1},
+		{"shift-jis", []byte{0x82}, utf8.RuneError, 1},
+		{"utf-8", []byte("日本"), '日', 3},
+		{"utf-8", []byte{0x80, 0x41}, utf8.RuneError, 1},
+		{"utf-16le", []byte{0x41, 0x00}, 'A', 2},
+		{"utf-16be", []byte{0x00, 0x41}, 'A', 2},
+		{"utf-16le", []byte{0x3d, 0xd8, 0x00, 0xde}, '😀', 4},
+	}
+
+	for _, test := range matrix {
+		c, err := util.LookupCharset(test.charset)
+		assert.NoError(err)
+		r, size := c.Decode(test.inp)
+		assert.Equal(test.expRune, r, "%s %x", test.charset, test.inp)
+		assert.Equal(test.expSize, size, "%s %x", test.charset, test.inp)
+	}
+}
+
+func TestCharset_Encode(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		charset  string
+		inp      string
+		expBytes []byte
+		expError bool
+	}{
+		{"ascii", "AB", []byte("AB"), false},
+		{"ascii", "é", nil, true},
+		{"latin1", "é", []byte{0xe9}, false},
+		{"cp437", "☺█", []byte{0x01, 0xdb}, false},
+		{"cp037", "A1", []byte{0xc1, 0xf1}, false},
+		{"cp500", "[", []byte{0x4a}, false},
+		{"shift-jis", "aあ", []byte{0x61, 0x82, 0xa0}, false},
+		{"utf-8", "é", []byte{0xc3, 0xa9}, false},
+		{"utf-16be", "A😀", []byte{0x00, 0x41, 0xd8, 0x3d, 0xde, 0x00}, false},
+	}
+
+	for _, test := range matrix {
+		c, err := util.LookupCharset(test.charset)
+		assert.NoError(err)
+		b, err := c.Encode(test.inp)
+		if test.expError {
+			assert.Error(err)
+		} else {
+			assert.NoError(err)
+			assert.Equal(test.expBytes, b, "%s %q", test.charset, test.inp)
+		}
+	}
+
+	_, err := util.LookupCharset("nope")
+	assert.Error(err)
+}
@@ -0,0 +1,303 @@
+package util
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CRCParams describes a CRC in the Rocksoft model. Poly is written in normal
+// (MSB-first) form, even for reflected CRCs.
+type CRCParams struct {
+	Width  int
+	Poly   uint64
+	Init   uint64
+	RefIn  bool
+	RefOut bool
+	XorOut uint64
+}
+
+// crcPresets are the named CRCs. The plain crcN names are the most common
+// variant of each width.
+var crcPresets = map[string]CRCParams{
+	"crc8":         {Width: 8, Poly: 0x07},
+	"crc8-maxim":   {Width: 8, Poly: 0x31, RefIn: true, RefOut: true},
+	"crc16":        {Width: 16, Poly: 0x8005, RefIn: true, RefOut: true},
+	"crc16-ccitt":  {Width: 16, Poly: 0x1021, Init: 0xffff},
+	"crc16-xmodem": {Width: 16, Poly: 0x1021},
+	"crc16-modbus": {Width: 16, Poly: 0x8005, Init: 0xffff, RefIn: true, RefOut: true},
+	"crc32":        {Width: 32, Poly: 0x04c11db7, Init: 0xffffffff, RefIn: true, RefOut: true, XorOut: 0xffffffff},
+	"crc32c":       {Width: 32, Poly: 0x1edc6f41, Init: 0xffffffff, RefIn: true, RefOut: true, XorOut: 0xffffffff},
+	"crc32-bzip2":  {Width: 32, Poly: 0x04c11db7, Init: 0xffffffff, XorOut: 0xffffffff},
+	"crc32-mpeg2":  {Width: 32, Poly: 0x04c11db7, Init: 0xffffffff},
+	"crc64":        {Width: 64, Poly: 0x42f0e1eba9ea3693, Init: ^uint64(0), RefIn: true, RefOut: true, XorOut: ^uint64(0)},
+	"crc64-ecma":   {Width: 64, Poly: 0x42f0e1eba9ea3693},
+	"crc64-iso":    {Width: 64, Poly: 0x1b, Init: ^uint64(0), RefIn: true, RefOut: true, XorOut: ^uint64(0)},
+}
+
+// hashes are the algorithms other than CRCs.
+var hashes = map[string]func() hash.Hash{
+	"adler32":    func() hash.Hash { return adler32.New() },
+	"fletcher16": func() hash.Hash { return &fletcher{size: 1} },
+	"fletcher32": func() hash.Hash { return &fletcher{size: 2} },
+	"sum8":       func() hash.Hash { return &byteSum{size: 1} },
+	"sum16":      func() hash.Hash { return &byteSum{size: 2} },
+	"sum32":      func() hash.Hash { return &byteSum{size: 4} },
+	"xor8":       func() hash.Hash { return &byteSum{size: 1, xor: true} },
+	"md5":        md5.New,
+	"sha1":       sha1.New,
+	"sha224":     sha256.New224,
+	"sha256":     sha256.New,
+	"sha384":     sha512.New384,
+	"sha512":     sha512.New,
+}
+
+// HashNames returns the names of the supported algorithms, sorted.
+func HashNames() []string {
+	names := make([]string, 0, len(crcPresets)+len(hashes))
+	for name := range crcPresets {
+		names = append(names, name)
+	}
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewHash returns a hash for an algorithm such as "crc32" or "sha256". The
+// parameters of a CRC can be changed by following its name with a colon and a
+// comma separated list of poly, init, xorout, refin, refout or reflect
+// settings, such as "crc16:poly=0x8005,init=0xffff".
+func NewHash(spec string) (hash.Hash, error) {
+	name, settings, _ := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+	if fn, ok := hashes[name]; ok && settings == "" {
+		return fn(), nil
+	}
+
+	params, ok := crcPresets[name]
+	if !ok {
+		if _, ok := hashes[name]; ok {
+			return nil, fmt.Errorf("%s does not take parameters", name)
+		}
+		return nil, fmt.Errorf("unknown algorithm: %s", name)
+	}
+	if settings != "" {
+		if err := params.parse(settings); err != nil {
+			return nil, err
+		}
+	}
+	return NewCRC(params), nil
+}
+
+// OrderSum returns a sum in the given byte order. Sums are big endian, and
+// those longer than 8 bytes are digests that are always kept as they are.
+func OrderSum(sum []byte, order binary.ByteOrder) []byte {
+	if order == binary.LittleEndian && len(sum) <= 8 {
+		return Reverse(sum)
+	}
+	return sum
+}
+
+// parse applies comma separated settings to the parameters.
+func (p *CRCParams) parse(settings string) error {
+	for _, setting := range strings.Split(settings, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(setting), "=")
+		switch key {
+		case "poly", "init", "xorout":
+			n, err := ParseNumber(value, 16)
+			if err != nil {
+				return err
+			}
+			if p.Width < 64 && n>>p.Width != 0 {
+				return fmt.Errorf("%s does not fit in %d bits: %s", key, p.Width, value)
+			}
+			switch key {
+			case "poly":
+				p.Poly = n
+			case "init":
+				p.Init = n
+			case "xorout":
+				p.XorOut = n
+			}
+		case "refin", "refout", "reflect":
+			b := true
+			if value != "" {
+				var err error
+				if b, err = strconv.ParseBool(value); err != nil {
+					return fmt.Errorf("invalid value for %s: %s", key, value)
+				}
+			}
+			if key != "refout" {
+				p.RefIn = b
+			}
+			if key != "refin" {
+				p.RefOut = b
+			}
+		default:
+			return fmt.Errorf("unknown CRC parameter: %s", key)
+		}
+	}
+	return nil
+}
+
+// reflectBits reverses the lowest width bits of v.
+func reflectBits(v uint64, width int) uint64 {
+	var r uint64
+	for i := 0; i < width; i++ {
+		r = r<<1 | v&1
+		v >>= 1
+	}
+	return r
+}
+
+// crc is a table driven CRC of 8 to 64 bits. With reflected input, the
+// register holds the CRC in reflected form.
+type crc struct {
+	p     CRCParams
+	mask  uint64
+	table [256]uint64
+	reg   uint64
+}
+
+// NewCRC returns a hash that computes a CRC. The sum is big endian.
+func NewCRC(p CRCParams) hash.Hash {
+	c := &crc{p: p, mask: ^uint64(0) >> (64 - p.Width)}
+	for i := range c.table {
+		if p.RefIn {
+			poly := reflectBits(p.Poly, p.Width)
+			v := uint64(i)
+			for j := 0; j < 8; j++ {
+				if v&1 != 0 {
+					v = v>>1 ^ poly
+				} else {
+					v >>= 1
+				}
+			}
+			c.table[i] = v
+		} else {
+			top := uint64(1) << (p.Width - 1)
+			v := uint64(i) << (p.Width - 8)
+			for j := 0; j < 8; j++ {
+				if v&top != 0 {
+					v = v<<1 ^ p.Poly
+				} else {
+					v <<= 1
+				}
+			}
+			c.table[i] = v & c.mask
+		}
+	}
+	c.Reset()
+	return c
+}
+
+func (c *crc) Reset() {
+	c.reg = c.p.Init
+	if c.p.RefIn {
+		c.reg = reflectBits(c.p.Init, c.p.Width)
+	}
+}
+
+func (c *crc) Write(b []byte) (int, error) {
+	for _, x := range b {
+		if c.p.RefIn {
+			c.reg = c.reg>>8 ^ c.table[byte(c.reg)^x]
+		} else {
+			c.reg = (c.reg<<8 ^ c.table[byte(c.reg>>(c.p.Width-8))^x]) & c.mask
+		}
+	}
+	return len(b), nil
+}
+
+func (c *crc) Sum(b []byte) []byte {
+	v := c.reg
+	if c.p.RefIn != c.p.RefOut {
+		v = reflectBits(v, c.p.Width)
+	}
+	return append(b, putUint(v^c.p.XorOut, c.Size(), binary.BigEndian)...)
+}
+
+func (c *crc) Size() int      { return c.p.Width / 8 }
+func (c *crc) BlockSize() int { return 1 }
+
+// fletcher computes Fletcher-16 over bytes, or Fletcher-32 over little endian
+// 16-bit words. An odd trailing byte is padded with zero.
+type fletcher struct {
+	size   int
+	a, b   uint64
+	odd    bool
+	oddVal byte
+}
+
+func (f *fletcher) Reset() { f.a, f.b, f.odd = 0, 0, false }
+
+func (f *fletcher) Write(p []byte) (int, error) {
+	mod := uint64(255)
+	if f.size == 2 {
+		mod = 65535
+	}
+	for _, x := range p {
+		v := uint64(x)
+		if f.size == 2 {
+			if !f.odd {
+				f.odd, f.oddVal = true, x
+				continue
+			}
+			f.odd = false
+			v = uint64(f.oddVal) | uint64(x)<<8
+		}
+		f.a = (f.a + v) % mod
+		f.b = (f.b + f.a) % mod
+	}
+	return len(p), nil
+}
+
+func (f *fletcher) Sum(b []byte) []byte {
+	a, bb := f.a, f.b
+	if f.odd {
+		mod := uint64(65535)
+		a = (a + uint64(f.oddVal)) % mod
+		bb = (bb + a) % mod
+	}
+	shift := 8 * f.size
+	return append(b, putUint(bb<<shift|a, f.Size(), binary.BigEndian)...)
+}
+
+func (f *fletcher) Size() int      { return 2 * f.size }
+func (f *fletcher) BlockSize() int { return f.size }
+
+// byteSum adds or XORs all bytes, truncated to size bytes.
+type byteSum struct {
+	size int
+	xor  bool
+	sum  uint64
+}
+
+func (s *byteSum) Reset() { s.sum = 0 }
+
+func (s *byteSum) Write(p []byte) (int, error) {
+	for _, x := range p {
+		if s.xor {
+			s.sum ^= uint64(x)
+		} else {
+			s.sum += uint64(x)
+		}
+	}
+	return len(p), nil
+}
+
+func (s *byteSum) Sum(b []byte) []byte {
+	return append(b, putUint(s.sum, s.size, binary.BigEndian)...)
+}
+
+func (s *byteSum) Size() int      { return s.size }
+func (s *byteSum) BlockSize() int { return 1 }
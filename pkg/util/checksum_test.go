@@ -0,0 +1,102 @@
+package util_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHash(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		spec     string
+		inp      string
+		expected string
+		expError bool
+	}{
+		{"crc8", "123456789", "f4", false},
+		{"crc8-maxim", "123456789", "a1", false},
+		{"crc16", "123456789", "bb3d", false},
+		{"crc16-ccitt", "123456789", "29b1", false},
+		{"crc16-xmodem", "123456789", "31c3", false},
+		{"crc16-modbus", "123456789", "4b37", false},
+		{"crc32", "123456789", "cbf43926", false},
+		{"CRC32", "123456789", "cbf43926", false},
+		{"crc32c", "123456789", "e3069283", false},
+		{"crc32-bzip2", "123456789", "fc891918", false},
+		{"crc32-mpeg2", "123456789", "0376e6e7", false},
+		{"crc64", "123456789", "995dc9bbdf1939fa", false},
+		{"crc64-ecma", "123456789", "6c40df5f0b497347", false},
+		{"crc64-iso", "123456789", "b90956c775a41001", false},
+		{"crc32:poly=0x1edc6f41", "123456789", "e3069283", false},
+		{"crc16-ccitt:init=0", "123456789", "31c3", false},
+		{"crc16:reflect=false", "123456789", "fee8", false},
+		{"crc16:refout=false", "123456789", "bcdd", false},
+		{"crc8:poly=100", "", "", true},
+		{"crc8:foo=1", "", "", true},
+		{"crc8:refin=maybe", "", "", true},
+		{"adler32", "123456789", "091e01de", false},
+		{"fletcher16", "abcde", "c8f0", false},
+		{"fletcher32", "abcde", "f04fc729", false},
+		{"fletcher32", "abcdef", "56502d2a", false},
+		{"sum8", "123456789", "dd", false},
+		{"sum16", "123456789", "01dd", false},
+		{"sum32", "123456789", "000001dd", false},
+		{"xor8", "123456789", "31", false},
+		{"md5", "123456789", "25f9e794323b453885f5181f1b624d0b", false},
+		{"sha1", "abc", "a9993e364706816aba3e25717850c26c9cd0d89d", false},
+		{"sha256", "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", false},
+		{"sha256:poly=1", "", "", true},
+		{"whirlpool", "", "", true},
+	}
+
+	for _, test := range matrix {
+		h, err := util.NewHash(test.spec)
+		if test.expError {
+			assert.Error(err, test.spec)
+			continue
+		}
+		if !assert.NoError(err, test.spec) {
+			continue
+		}
+
+		// Write one byte at a time to check that sums carry over writes
+		for i := 0; i < len(test.inp); i++ {
+			h.Write([]byte{test.inp[i]})
+		}
+		assert.Equal(test.expected, hex.EncodeToString(h.Sum(nil)), test.spec)
+		assert.Equal(len(test.expected)/2, h.Size(), test.spec)
+
+		h.Reset()
+		h.Write([]byte(test.inp))
+		assert.Equal(test.expected, hex.EncodeToString(h.Sum(nil)), test.spec)
+	}
+}
+
+func TestHashNames(t *testing.T) {
+	assert := assert.New(t)
+
+	names := util.HashNames()
+	assert.Contains(names, "crc32")
+	assert.Contains(names, "sha512")
+	for _, name := range names {
+		_, err := util.NewHash(name)
+		assert.NoError(err, name)
+	}
+}
+
+func TestOrderSum(t *testing.T) {
+	assert := assert.New(t)
+
+	sum := []byte{1, 2, 3, 4}
+	assert.Equal([]byte{4, 3, 2, 1}, util.OrderSum(sum, binary.LittleEndian))
+	assert.Equal([]byte{1, 2, 3, 4}, util.OrderSum(sum, binary.BigEndian))
+
+	digest := make([]byte, 16)
+	digest[0] = 1
+	assert.Equal(digest, util.OrderSum(digest, binary.LittleEndian))
+}
@@ -0,0 +1,210 @@
+package util
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Codec is a reversible transform of bytes, such as a compression format or
+// a text encoding.
+type Codec struct {
+	Name   string
+	Encode func(data []byte) ([]byte, error)
+	Decode func(data []byte) ([]byte, error)
+
+	// DecodeStream decodes like Decode, and also returns the number of bytes
+	// that were decoded, as a compressed stream can end before the data does.
+	DecodeStream func(data []byte) ([]byte, int, error)
+
+	// Pad returns n bytes that can follow the encoded data without changing
+	// what it decodes to. It is nil for codecs that decode all of the data.
+	Pad func(n int) []byte
+}
+
+// codecs are the supported codecs, keyed by name.
+var codecs = map[string]*Codec{
+	"zlib": {
+		Encode:       compress(func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil }),
+		Pad:          padWith(0),
+		DecodeStream: decompress(func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }),
+	},
+	"deflate": {
+		Encode:       compress(func(w io.Writer) (io.WriteCloser, error) { return flate.NewWriter(w, flate.DefaultCompression) }),
+		Pad:          padWith(0),
+		DecodeStream: decompress(func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }),
+	},
+	"gzip": {
+		Encode: compress(func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }),
+		Pad:    padWith(0),
+		DecodeStream: decompress(func(r io.Reader) (io.Reader, error) {
+			// Ignore anything after the first member, such as padding
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			zr.Multistream(false)
+			return zr, nil
+		}),
+	},
+	"lz4": {
+		Encode:       compress(func(w io.Writer) (io.WriteCloser, error) { return lz4.NewWriter(w), nil }),
+		Pad:          padWith(0),
+		DecodeStream: decompress(func(r io.Reader) (io.Reader, error) { return lz4.NewReader(r), nil }),
+	},
+	"zstd": {
+		Encode: func(data []byte) ([]byte, error) {
+			enc, err := zstd.NewWriter(nil)
+			if err != nil {
+				return nil, err
+			}
+			defer enc.Close()
+			return enc.EncodeAll(data, nil), nil
+		},
+		Decode: func(data []byte) ([]byte, error) {
+			dec, err := zstd.NewReader(nil)
+			if err != nil {
+				return nil, err
+			}
+			defer dec.Close()
+			return dec.DecodeAll(data, nil)
+		},
+	},
+	"base64":    textCodec(base64.StdEncoding.EncodeToString, base64.StdEncoding.DecodeString),
+	"base64url": textCodec(base64.URLEncoding.EncodeToString, base64.URLEncoding.DecodeString),
+	"base32":    textCodec(base32.StdEncoding.EncodeToString, base32.StdEncoding.DecodeString),
+	"hex":       textCodec(hex.EncodeToString, hex.DecodeString),
+	"url":       textCodec(urlEncode, urlDecode),
+}
+
+func init() {
+	for name, c := range codecs {
+		c.Name = name
+		if c.Decode == nil {
+			decodeStream := c.DecodeStream
+			c.Decode = func(data []byte) ([]byte, error) {
+				out, _, err := decodeStream(data)
+				return out, err
+			}
+		}
+		if c.DecodeStream == nil {
+			decode := c.Decode
+			c.DecodeStream = func(data []byte) ([]byte, int, error) {
+				out, err := decode(data)
+				return out, len(data), err
+			}
+		}
+	}
+}
+
+// LookupCodec returns the codec with the given name.
+func LookupCodec(name string) (*Codec, error) {
+	c, ok := codecs[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec: %s", name)
+	}
+	return c, nil
+}
+
+// CodecNames returns the names of the supported codecs, sorted.
+func CodecNames() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compress returns an encoder that writes the data through a compressor.
+func compress(newWriter func(w io.Writer) (io.WriteCloser, error)) func([]byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		w, err := newWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// decompress returns a decoder that reads the data through a decompressor.
+// Data after the end of the compressed stream is ignored, and not counted as
+// decoded. The decompressors read a bytes.Reader one byte at a time rather
+// than buffering it, so what is left of it is what follows the stream.
+func decompress(newReader func(r io.Reader) (io.Reader, error)) func([]byte) ([]byte, int, error) {
+	return func(data []byte) ([]byte, int, error) {
+		br := bytes.NewReader(data)
+		r, err := newReader(br)
+		if err != nil {
+			return nil, 0, err
+		}
+		out, err := io.ReadAll(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		return out, len(data) - br.Len(), nil
+	}
+}
+
+// padWith returns a padding function that repeats the byte.
+func padWith(b byte) func(n int) []byte {
+	return func(n int) []byte {
+		return bytes.Repeat([]byte{b}, n)
+	}
+}
+
+// textCodec returns a codec for a text encoding. Whitespace is removed
+// before decoding.
+func textCodec(encode func([]byte) string, decode func(string) ([]byte, error)) *Codec {
+	return &Codec{
+		Pad: padWith(' '),
+		Encode: func(data []byte) ([]byte, error) {
+			return []byte(encode(data)), nil
+		},
+		Decode: func(data []byte) ([]byte, error) {
+			return decode(strings.Join(strings.Fields(string(data)), ""))
+		},
+	}
+}
+
+// urlEncode percent-encodes every byte except the unreserved characters of
+// RFC 3986.
+func urlEncode(data []byte) string {
+	var sb strings.Builder
+	for _, c := range data {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// urlDecode decodes percent-encoded data. Unlike in query strings, '+' is
+// left as it is.
+func urlDecode(s string) ([]byte, error) {
+	text, err := url.PathUnescape(s)
+	return []byte(text), err
+}
@@ -0,0 +1,112 @@
+package util_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	data := append(bytes.Repeat([]byte("hello, gex! "), 64), 0x00, 0xff, '+', '/', ' ')
+	for _, name := range util.CodecNames() {
+		c, err := util.LookupCodec(name)
+		if !assert.NoError(err, name) {
+			continue
+		}
+		assert.Equal(name, c.Name)
+
+		enc, err := c.Encode(data)
+		assert.NoError(err, name)
+		dec, err := c.Decode(enc)
+		assert.NoError(err, name)
+		assert.Equal(data, dec, name)
+	}
+
+	_, err := util.LookupCodec("rot13")
+	assert.Error(err)
+}
+
+func TestCodecDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		codec    string
+		inp      string
+		expected string
+		expError bool
+	}{
+		{"base64", "aGVsbG8=", "hello", false},
+		{"base64", "aGVs\nbG8=\n", "hello", false},
+		{"base64url", "-_8=", "\xfb\xff", false},
+		{"base32", "NBSWY3DP", "hello", false},
+		{"hex", "68 65 6c 6c 6f", "hello", false},
+		{"url", "a%20b+c", "a b+c", false},
+		{"zlib", "\x78\x9c\xcb\x48\xcd\xc9\xc9\x07\x00\x06\x2c\x02\x15", "hello", false},
+		{"zlib", "\x78\x9c\xcb\x48\xcd\xc9\xc9\x07\x00\x06\x2c\x02\x15\xff\xff", "hello", false},
+		{"deflate", "\xcb\x48\xcd\xc9\xc9\x07\x00", "hello", false},
+		{"base64", "!!!", "", true},
+		{"zlib", "hello", "", true},
+		{"gzip", "hello", "", true},
+		{"zstd", "hello", "", true},
+	}
+
+	for _, test := range matrix {
+		c, err := util.LookupCodec(test.codec)
+		if !assert.NoError(err, test.codec) {
+			continue
+		}
+		out, err := c.Decode([]byte(test.inp))
+		if test.expError {
+			assert.Error(err, "%s %q", test.codec, test.inp)
+		} else {
+			assert.NoError(err, "%s %q", test.codec, test.inp)
+			assert.Equal(test.expected, string(out), "%s %q", test.codec, test.inp)
+		}
+	}
+
+	// Data after the end of a gzip member is ignored, and not counted as
+	// decoded
+	c, _ := util.LookupCodec("gzip")
+	enc, err := c.Encode([]byte("hello"))
+	assert.NoError(err)
+	out, err := c.Decode(append(enc, 0, 0, 0, 0))
+	assert.NoError(err)
+	assert.Equal("hello", string(out))
+	out, n, err := c.DecodeStream(append(enc, 0, 0, 0, 0))
+	assert.NoError(err)
+	assert.Equal("hello", string(out))
+	assert.Equal(len(enc), n)
+}
+
+func TestCodecDecodeStream(t *testing.T) {
+	assert := assert.New(t)
+
+	data := bytes.Repeat([]byte("hello, gex! "), 64)
+	for _, name := range util.CodecNames() {
+		c, _ := util.LookupCodec(name)
+		enc, err := c.Encode(data)
+		if !assert.NoError(err, name) {
+			continue
+		}
+
+		// Padding after the stream decodes to the same data
+		padded := enc
+		if c.Pad != nil {
+			padded = append(append([]byte{}, enc...), c.Pad(16)...)
+		}
+		dec, n, err := c.DecodeStream(padded)
+		if !assert.NoError(err, name) {
+			continue
+		}
+		assert.Equal(data, dec, name)
+		assert.LessOrEqual(len(enc), n, name)
+		if c.Pad != nil && c.Pad(1)[0] == 0 {
+			// Compressed streams end before the padding
+			assert.Equal(len(enc), n, name)
+		}
+	}
+}
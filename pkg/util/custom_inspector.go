@@ -0,0 +1,195 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+)
+
+// MaxCustomRowWidth is the maximum number of bytes a custom row can decode.
+const MaxCustomRowWidth = 256
+
+// CustomRow is an inspector row defined by the user. The value is produced by
+// a Go template that is executed with a CustomValue.
+type CustomRow struct {
+	Name      string
+	Width     int
+	ByteOrder binary.ByteOrder
+	Source    string
+
+	tmpl *template.Template
+}
+
+// CustomValue is the data passed to the template of a custom row.
+type CustomValue struct {
+	// Bytes are the bytes at the cursor, Width bytes long.
+	Bytes []byte
+
+	// Uint and Int are the bytes read as an unsigned and signed integer in the
+	// row's byte order. They are only set if Width is at most 8.
+	Uint uint64
+	Int  int64
+
+	// Float is the bytes read as a float if Width is 4 or 8.
+	Float float64
+}
+
+// customFuncs are the functions available to the templates of custom rows,
+// in addition to the standard template functions.
+var customFuncs = template.FuncMap{
+	// fixed converts a fixed point number with the given number of fraction
+	// bits, such as Q16.16 with {{fixed .Int 16}}.
+	"fixed": func(v any, fracBits int) (float64, error) {
+		f, err := toFloat(v)
+		return f / math.Exp2(float64(fracBits)), err
+	},
+	"add": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x + y }) },
+	"sub": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x - y }) },
+	"mul": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x * y }) },
+	"div": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x / y }) },
+
+	// hex formats bytes as a hex string.
+	"hex": hex.EncodeToString,
+
+	// xor applies a repeating XOR key to the bytes.
+	"xor": func(b []byte, key ...byte) []byte {
+		out := make([]byte, len(b))
+		for i := range b {
+			out[i] = b[i]
+			if len(key) > 0 {
+				out[i] ^= key[i%len(key)]
+			}
+		}
+		return out
+	},
+
+	// str converts bytes to a string up to the first null byte, replacing
+	// non-printable bytes with dots.
+	"str": func(b []byte) string {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		var sb strings.Builder
+		for _, c := range b {
+			if c < 0x20 || c >= 0x7f {
+				c = '.'
+			}
+			sb.WriteByte(c)
+		}
+		return sb.String()
+	},
+
+	// uint and int read an integer of size bytes at the given offset in the
+	// row's bytes, using the byte order given as "le" or "be".
+	"uint": func(b []byte, offset, size int, order string) (uint64, error) {
+		return readField(b, offset, size, order)
+	},
+	"int": func(b []byte, offset, size int, order string) (int64, error) {
+		v, err := readField(b, offset, size, order)
+		return signExtend(v, size*8), err
+	},
+}
+
+// toFloat converts a number passed to a template function to a float64.
+func toFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+// floatOp applies an arithmetic operation to two template numbers.
+func floatOp(a, b any, op func(x, y float64) float64) (float64, error) {
+	x, err := toFloat(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toFloat(b)
+	if err != nil {
+		return 0, err
+	}
+	return op(x, y), nil
+}
+
+// readField reads an integer field from a custom row's bytes.
+func readField(b []byte, offset, size int, order string) (uint64, error) {
+	byteOrder, err := ParseByteOrder(order)
+	if err != nil {
+		return 0, err
+	}
+	if size < 1 || size > 8 || offset < 0 || offset+size > len(b) {
+		return 0, fmt.Errorf("field out of range")
+	}
+	return getUint(b[offset:], size, byteOrder), nil
+}
+
+// ParseByteOrder parses a byte order name.
+func ParseByteOrder(value string) (binary.ByteOrder, error) {
+	switch strings.ToLower(value) {
+	case "big", "be", "b":
+		return binary.BigEndian, nil
+	case "little", "le", "l":
+		return binary.LittleEndian, nil
+	}
+	return nil, fmt.Errorf("expected either big or little")
+}
+
+// NewCustomRow creates a custom inspector row that decodes width bytes with
+// the template.
+func NewCustomRow(name string, width int, byteOrder binary.ByteOrder, source string) (*CustomRow, error) {
+	if width < 1 || width > MaxCustomRowWidth {
+		return nil, fmt.Errorf("width must be between 1 and %d", MaxCustomRowWidth)
+	}
+
+	tmpl, err := template.New(name).Funcs(customFuncs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CustomRow{
+		Name:      name,
+		Width:     width,
+		ByteOrder: byteOrder,
+		Source:    source,
+		tmpl:      tmpl,
+	}, nil
+}
+
+// Inspect decodes the bytes at the start of buf. It returns false if buf is
+// shorter than the row's width. Template errors are shown as the value.
+func (c *CustomRow) Inspect(buf []byte) (Row, bool) {
+	if len(buf) < c.Width {
+		return Row{}, false
+	}
+
+	v := CustomValue{Bytes: buf[:c.Width]}
+	if c.Width <= 8 {
+		v.Uint = getUint(buf, c.Width, c.ByteOrder)
+		v.Int = signExtend(v.Uint, c.Width*8)
+	}
+	switch c.Width {
+	case 4:
+		v.Float = float64(math.Float32frombits(uint32(v.Uint)))
+	case 8:
+		v.Float = math.Float64frombits(v.Uint)
+	}
+
+	var sb strings.Builder
+	if err := c.tmpl.Execute(&sb, v); err != nil {
+		return Row{Key: c.Name, Val: "error: " + err.Error()}, true
+	}
+	return Row{Key: c.Name, Val: sb.String()}, true
+}
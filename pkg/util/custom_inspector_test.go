@@ -0,0 +1,54 @@
+package util_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomRow_Inspect(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		width    int
+		order    binary.ByteOrder
+		source   string
+		inp      []byte
+		expected string
+	}{
+		{4, binary.BigEndian, "{{fixed .Int 16}}", []byte{0x00, 0x01, 0x80, 0x00}, "1.5"},
+		{4, binary.BigEndian, "{{fixed .Int 16}}", []byte{0xff, 0xff, 0x00, 0x00}, "-1"},
+		{2, binary.LittleEndian, "{{.Uint}} / {{.Int}}", []byte{0xff, 0xff}, "65535 / -1"},
+		{4, binary.LittleEndian, "{{printf \"%.2f\" .Float}}", []byte{0x00, 0x00, 0x80, 0x3f}, "1.00"},
+		{4, binary.LittleEndian, "{{str (xor .Bytes 0x20)}}", []byte("HEX\x20"), "hex"},
+		{3, binary.LittleEndian, "{{hex .Bytes}}", []byte{0xde, 0xad, 0xbe, 0xef}, "deadbe"},
+		{4, binary.LittleEndian, "{{uint .Bytes 2 2 \"be\"}}", []byte{0x00, 0x00, 0x01, 0x02}, "258"},
+		{1, binary.LittleEndian, "{{mul .Uint 2}}", []byte{0x15}, "42"},
+		{1, binary.LittleEndian, "{{.Nope}}", []byte{0x00}, "error: template: row:1:2: executing \"row\" at <.Nope>: can't evaluate field Nope in type util.CustomValue"},
+		{16, binary.LittleEndian, "{{len .Bytes}}", make([]byte, 16), "16"},
+	}
+
+	for _, test := range matrix {
+		c, err := util.NewCustomRow("row", test.width, test.order, test.source)
+		if !assert.NoError(err, test.source) {
+			continue
+		}
+		row, ok := c.Inspect(test.inp)
+		assert.True(ok, test.source)
+		assert.Equal("row", row.Key)
+		assert.Equal(test.expected, row.Val, test.source)
+	}
+
+	// Rows that do not fit in the buffer are hidden
+	c, err := util.NewCustomRow("row", 4, binary.LittleEndian, "{{.Uint}}")
+	assert.NoError(err)
+	_, ok := c.Inspect([]byte{0x00})
+	assert.False(ok)
+
+	_, err = util.NewCustomRow("row", 4, binary.LittleEndian, "{{.Uint")
+	assert.Error(err)
+	_, err = util.NewCustomRow("row", 0, binary.LittleEndian, "{{.Uint}}")
+	assert.Error(err)
+}
@@ -2,7 +2,11 @@ package util
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"time"
 	"unicode"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -15,6 +19,84 @@ import (
 type Row struct {
 	Key string
 	Val string
+
+	// Encode parses a new value for the row and returns its bytes, or is nil
+	// if the row cannot be edited.
+	Encode func(s string) ([]byte, error)
+}
+
+// parseNumber parses an integer typed into the inspector. Thousands
+// separators are ignored, and the base can be given with a prefix.
+func parseNumber(s string, signed bool, bitSize int) (uint64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if signed {
+		v, err := strconv.ParseInt(s, 0, bitSize)
+		return uint64(v), err
+	}
+	return strconv.ParseUint(s, 0, bitSize)
+}
+
+// intEncoder returns an encoder for integers of the given size in bytes.
+func intEncoder(size int, signed bool, byteOrder binary.ByteOrder) func(string) ([]byte, error) {
+	return func(s string) ([]byte, error) {
+		v, err := parseNumber(s, signed, size*8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", s)
+		}
+		return putUint(v, size, byteOrder), nil
+	}
+}
+
+// parseFloat parses a float typed into the inspector.
+func parseFloat(s string, bitSize int) (float64, error) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", s)
+	}
+	return v, nil
+}
+
+// timeEncoder returns an encoder for timestamps of the given size in bytes.
+// The value can either be a date and time in UTC, or a raw number.
+func timeEncoder(size int, byteOrder binary.ByteOrder, bits func(t time.Time) (uint64, error)) func(string) ([]byte, error) {
+	return func(s string) ([]byte, error) {
+		if v, err := parseNumber(s, false, size*8); err == nil {
+			return putUint(v, size, byteOrder), nil
+		}
+		t, err := parseTime(s)
+		if err != nil {
+			return nil, err
+		}
+		v, err := bits(t)
+		if err != nil {
+			return nil, err
+		}
+		return putUint(v, size, byteOrder), nil
+	}
+}
+
+// floatEncoder returns an encoder for floats of the given size in bytes.
+func floatEncoder(size int, byteOrder binary.ByteOrder) func(string) ([]byte, error) {
+	return func(s string) ([]byte, error) {
+		v, err := parseFloat(s, size*8)
+		if err != nil {
+			return nil, err
+		}
+		if size == 4 {
+			return putUint(uint64(math.Float32bits(float32(v))), size, byteOrder), nil
+		}
+		return putUint(math.Float64bits(v), size, byteOrder), nil
+	}
+}
+
+// putUint encodes the lowest size bytes of v in the given byte order.
+func putUint(v uint64, size int, byteOrder binary.ByteOrder) []byte {
+	buf := make([]byte, 8)
+	byteOrder.PutUint64(buf, v)
+	if byteOrder == binary.BigEndian {
+		return buf[8-size:]
+	}
+	return buf[:size]
 }
 
 // Inspect returns a list of string representations of the byte slice.
@@ -23,26 +105,140 @@ func Inspect(buf []byte, byteOrder binary.ByteOrder) []Row {
 	res := make([]Row, 0)
 
 	if len(buf) >= 1 {
-		res = append(res, Row{"Binary", p.Sprintf("%08b", buf[0])})
-		res = append(res, Row{"Uint8", p.Sprintf("%d", buf[0])})
-		res = append(res, Row{"Int8", p.Sprintf("%d", int8(buf[0]))})
+		res = append(res, Row{"Binary", p.Sprintf("%08b", buf[0]), func(s string) ([]byte, error) {
+			v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(s), "0b"), 2, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid binary number: %s", s)
+			}
+			return []byte{byte(v)}, nil
+		}})
+		res = append(res, Row{"Octal", p.Sprintf("%03o", buf[0]), func(s string) ([]byte, error) {
+			v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(s), "0o"), 8, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid octal number: %s", s)
+			}
+			return []byte{byte(v)}, nil
+		}})
+		res = append(res, Row{"Uint8", p.Sprintf("%d", buf[0]), intEncoder(1, false, byteOrder)})
+		res = append(res, Row{"Int8", p.Sprintf("%d", int8(buf[0])), intEncoder(1, true, byteOrder)})
 	}
 
 	if len(buf) >= 2 {
-		res = append(res, Row{"Uint16", p.Sprintf("%d", byteOrder.Uint16(buf))})
-		res = append(res, Row{"Int16", p.Sprintf("%d", int16(byteOrder.Uint16(buf)))})
+		res = append(res, Row{"Uint16", p.Sprintf("%d", byteOrder.Uint16(buf)), intEncoder(2, false, byteOrder)})
+		res = append(res, Row{"Int16", p.Sprintf("%d", int16(byteOrder.Uint16(buf))), intEncoder(2, true, byteOrder)})
+		res = append(res, Row{"Float16", strconv.FormatFloat(float16Value(byteOrder.Uint16(buf)), 'g', -1, 32), func(s string) ([]byte, error) {
+			v, err := parseFloat(s, 32)
+			if err != nil {
+				return nil, err
+			}
+			return putUint(uint64(float16Bits(float32(v))), 2, byteOrder), nil
+		}})
+		res = append(res, Row{"BFloat16", strconv.FormatFloat(float64(math.Float32frombits(uint32(byteOrder.Uint16(buf))<<16)), 'g', -1, 32), func(s string) ([]byte, error) {
+			v, err := parseFloat(s, 32)
+			if err != nil {
+				return nil, err
+			}
+			return putUint(uint64(bfloat16Bits(float32(v))), 2, byteOrder), nil
+		}})
+	}
+
+	if len(buf) >= 3 {
+		v := getUint(buf, 3, byteOrder)
+		res = append(res, Row{"Uint24", p.Sprintf("%d", v), intEncoder(3, false, byteOrder)})
+		res = append(res, Row{"Int24", p.Sprintf("%d", signExtend(v, 24)), intEncoder(3, true, byteOrder)})
 	}
 
 	if len(buf) >= 4 {
-		res = append(res, Row{"Uint32", p.Sprintf("%d", byteOrder.Uint32(buf))})
-		res = append(res, Row{"Int32", p.Sprintf("%d", int32(byteOrder.Uint32(buf)))})
-		res = append(res, Row{"Float32", p.Sprintf("%f", math.Float32frombits(byteOrder.Uint32(buf)))})
+		res = append(res, Row{"Uint32", p.Sprintf("%d", byteOrder.Uint32(buf)), intEncoder(4, false, byteOrder)})
+		res = append(res, Row{"Int32", p.Sprintf("%d", int32(byteOrder.Uint32(buf))), intEncoder(4, true, byteOrder)})
+		res = append(res, Row{"Float32", p.Sprintf("%f", math.Float32frombits(byteOrder.Uint32(buf))), floatEncoder(4, byteOrder)})
+
+		if t, ok := formatTime(time.Unix(int64(int32(byteOrder.Uint32(buf))), 0)); ok {
+			res = append(res, Row{"Time32", t, timeEncoder(4, byteOrder, func(t time.Time) (uint64, error) {
+				if t.Unix() < math.MinInt32 || t.Unix() > math.MaxInt32 {
+					return 0, fmt.Errorf("time does not fit in 32 bits")
+				}
+				return uint64(t.Unix()), nil
+			})})
+		}
+
+		if dt, ok := dosTimeValue(byteOrder.Uint16(buf), byteOrder.Uint16(buf[2:])); ok {
+			t, _ := formatTime(dt)
+			res = append(res, Row{"DOS time", strings.TrimSuffix(t, " UTC"), func(s string) ([]byte, error) {
+				t, err := parseTime(s)
+				if err != nil {
+					return nil, err
+				}
+				dosTime, dosDate, err := dosTimeBits(t)
+				if err != nil {
+					return nil, err
+				}
+				return append(putUint(uint64(dosTime), 2, byteOrder), putUint(uint64(dosDate), 2, byteOrder)...), nil
+			}})
+		}
+
+		if bcd, ok := formatBCD(buf[:4]); ok {
+			res = append(res, Row{"BCD", bcd, func(s string) ([]byte, error) {
+				return parseBCD(s, 4)
+			}})
+		}
 	}
 
 	if len(buf) >= 8 {
-		res = append(res, Row{"Uint64", p.Sprintf("%d", byteOrder.Uint64(buf))})
-		res = append(res, Row{"Int64", p.Sprintf("%d", int64(byteOrder.Uint64(buf)))})
-		res = append(res, Row{"Float64", p.Sprintf("%f", math.Float64frombits(byteOrder.Uint64(buf)))})
+		res = append(res, Row{"Uint64", p.Sprintf("%d", byteOrder.Uint64(buf)), intEncoder(8, false, byteOrder)})
+		res = append(res, Row{"Int64", p.Sprintf("%d", int64(byteOrder.Uint64(buf))), intEncoder(8, true, byteOrder)})
+		res = append(res, Row{"Float64", p.Sprintf("%f", math.Float64frombits(byteOrder.Uint64(buf))), floatEncoder(8, byteOrder)})
+
+		if t, ok := formatTime(time.Unix(int64(byteOrder.Uint64(buf)), 0)); ok {
+			res = append(res, Row{"Time64", t, timeEncoder(8, byteOrder, func(t time.Time) (uint64, error) {
+				return uint64(t.Unix()), nil
+			})})
+		}
+
+		if t, ok := formatTime(filetimeValue(byteOrder.Uint64(buf))); ok {
+			res = append(res, Row{"FILETIME", t, timeEncoder(8, byteOrder, filetimeBits)})
+		}
+	}
+
+	if len(buf) >= 16 {
+		res = append(res, Row{"GUID", formatGUID(buf, true), func(s string) ([]byte, error) {
+			return parseGUID(s, true)
+		}})
+		res = append(res, Row{"UUID", formatGUID(buf, false), func(s string) ([]byte, error) {
+			return parseGUID(s, false)
+		}})
+	}
+
+	// Variable-length integers. New values are padded to the same size so
+	// that they do not shift the following bytes.
+	if v, size, ok := decodeULEB128(buf); ok {
+		plural := "s"
+		if size == 1 {
+			plural = ""
+		}
+		res = append(res, Row{"ULEB128", p.Sprintf("%d (%d byte%s)", v, size, plural), func(s string) ([]byte, error) {
+			v, err := parseNumber(s, false, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number: %s", s)
+			}
+			return encodeULEB128(v, size)
+		}})
+		res = append(res, Row{"ZigZag", p.Sprintf("%d", int64(v>>1)^-int64(v&1)), func(s string) ([]byte, error) {
+			v, err := parseNumber(s, true, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number: %s", s)
+			}
+			return encodeULEB128(v<<1^uint64(int64(v)>>63), size)
+		}})
+	}
+	if v, size, ok := decodeSLEB128(buf); ok {
+		res = append(res, Row{"SLEB128", p.Sprintf("%d", v), func(s string) ([]byte, error) {
+			v, err := parseNumber(s, true, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number: %s", s)
+			}
+			return encodeSLEB128(int64(v), size)
+		}})
 	}
 
 	inspectRune := func(rn rune, sz int) (string, string) {
@@ -64,7 +260,9 @@ func Inspect(buf []byte, byteOrder binary.ByteOrder) []Row {
 	rn, sz := utf8.DecodeRune(buf)
 	if rn != utf8.RuneError {
 		descr, name := inspectRune(rn, sz)
-		res = append(res, Row{"UTF-8", descr}, Row{"", name})
+		res = append(res, Row{"UTF-8", descr, func(s string) ([]byte, error) {
+			return []byte(s), nil
+		}}, Row{Val: name})
 	}
 
 	// UTF-16
@@ -81,7 +279,13 @@ func Inspect(buf []byte, byteOrder binary.ByteOrder) []Row {
 	sz = len(utf16.Encode([]rune{rn})) * 2
 	if rn != utf8.RuneError {
 		descr, name := inspectRune(rn, sz)
-		res = append(res, Row{"UTF-16", descr}, Row{"", name})
+		res = append(res, Row{"UTF-16", descr, func(s string) ([]byte, error) {
+			out := make([]byte, 0, len(s)*2)
+			for _, u := range utf16.Encode([]rune(s)) {
+				out = append(out, putUint(uint64(u), 2, byteOrder)...)
+			}
+			return out, nil
+		}}, Row{Val: name})
 	}
 
 	return res
@@ -0,0 +1,158 @@
+package util_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect_Encode(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		key      string
+		inp      string
+		order    binary.ByteOrder
+		expected []byte
+		expError bool
+	}{
+		{"Binary", "00001010", binary.LittleEndian, []byte{0x0a}, false},
+		{"Uint8", "255", binary.LittleEndian, []byte{0xff}, false},
+		{"Uint8", "256", binary.LittleEndian, nil, true},
+		{"Int8", "-1", binary.LittleEndian, []byte{0xff}, false},
+		{"Uint16", "0x1234", binary.BigEndian, []byte{0x12, 0x34}, false},
+		{"Int16", "-1", binary.LittleEndian, []byte{0xff, 0xff}, false},
+		{"Int16", "-32,768", binary.LittleEndian, []byte{0x00, 0x80}, false},
+		{"Uint32", "1,000,000", binary.LittleEndian, []byte{0x40, 0x42, 0x0f, 0x00}, false},
+		{"Float32", "1", binary.LittleEndian, []byte{0x00, 0x00, 0x80, 0x3f}, false},
+		{"Float32", "pi", binary.LittleEndian, nil, true},
+		{"Float64", "1", binary.BigEndian, []byte{0x3f, 0xf0, 0, 0, 0, 0, 0, 0}, false},
+		{"Int64", "-2", binary.BigEndian, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}, false},
+		{"UTF-8", "é", binary.LittleEndian, []byte{0xc3, 0xa9}, false},
+		{"UTF-16", "A", binary.BigEndian, []byte{0x00, 0x41}, false},
+		{"Octal", "17", binary.LittleEndian, []byte{0x0f}, false},
+		{"Float16", "1", binary.LittleEndian, []byte{0x00, 0x3c}, false},
+		{"Float16", "65520", binary.LittleEndian, []byte{0x00, 0x7c}, false},
+		{"Float16", "0.1", binary.BigEndian, []byte{0x2e, 0x66}, false},
+		{"BFloat16", "3.14", binary.BigEndian, []byte{0x40, 0x49}, false},
+		{"Int24", "-2", binary.BigEndian, []byte{0xff, 0xff, 0xfe}, false},
+		{"Uint24", "16777216", binary.BigEndian, nil, true},
+		{"Time32", "2001-02-03 04:05:06", binary.BigEndian, []byte{0x3a, 0x7b, 0x83, 0x72}, false},
+		{"Time32", "0x10", binary.BigEndian, []byte{0, 0, 0, 0x10}, false},
+		{"Time32", "2100-01-01", binary.BigEndian, nil, true},
+		{"Time32", "yesterday", binary.BigEndian, nil, true},
+		{"DOS time", "2001-02-17 12:35:59", binary.LittleEndian, []byte{0x7d, 0x64, 0x51, 0x2a}, false},
+		{"DOS time", "1970-01-01", binary.LittleEndian, nil, true},
+		{"BCD", "1234", binary.LittleEndian, []byte{0x00, 0x00, 0x12, 0x34}, false},
+		{"BCD", "12a4", binary.LittleEndian, nil, true},
+		{"FILETIME", "1970-01-01T00:00:00Z", binary.LittleEndian, []byte{0x00, 0x80, 0x3e, 0xd5, 0xde, 0xb1, 0x9d, 0x01}, false},
+		{"GUID", "{00112233-4455-6677-8899-aabbccddeeff}", binary.LittleEndian, []byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, false},
+		{"UUID", "00112233445566778899aabbccddeeff", binary.LittleEndian, []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, false},
+		{"GUID", "0011", binary.LittleEndian, nil, true},
+	}
+
+	for _, test := range matrix {
+		// Some rows are only shown for valid values, so look for the row in
+		// a zeroed buffer and in a buffer that holds a DOS date and time
+		var row *util.Row
+		for _, buf := range [][]byte{make([]byte, 16), append([]byte{0x7d, 0x64, 0x51, 0x2a}, make([]byte, 12)...)} {
+			for _, r := range util.Inspect(buf, test.order) {
+				if row == nil && r.Key == test.key {
+					r := r
+					row = &r
+				}
+			}
+		}
+		if !assert.NotNil(row, test.key) || !assert.NotNil(row.Encode, test.key) {
+			continue
+		}
+
+		b, err := row.Encode(test.inp)
+		if test.expError {
+			assert.Error(err, "%s %s", test.key, test.inp)
+		} else {
+			assert.NoError(err, "%s %s", test.key, test.inp)
+			assert.Equal(test.expected, b, "%s %s", test.key, test.inp)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      []byte
+		order    binary.ByteOrder
+		key      string
+		expected string
+	}{
+		{[]byte{0x08}, binary.LittleEndian, "Octal", "010"},
+		{[]byte{0x00, 0x3c}, binary.LittleEndian, "Float16", "1"},
+		{[]byte{0xc0, 0x00}, binary.BigEndian, "Float16", "-2"},
+		{[]byte{0x01, 0x00}, binary.LittleEndian, "Float16", "5.9604645e-08"},
+		{[]byte{0x3f, 0x80}, binary.BigEndian, "BFloat16", "1"},
+		{[]byte{0xff, 0xff, 0xff}, binary.LittleEndian, "Uint24", "16,777,215"},
+		{[]byte{0xff, 0xff, 0xff}, binary.LittleEndian, "Int24", "-1"},
+		{[]byte{0x80, 0x00, 0x00}, binary.BigEndian, "Int24", "-8,388,608"},
+		{[]byte{0x00, 0x00, 0x00, 0x00}, binary.LittleEndian, "Time32", "1970-01-01 00:00:00 UTC"},
+		{[]byte{0x80, 0x00, 0x00, 0x00}, binary.BigEndian, "Time32", "1901-12-13 20:45:52 UTC"},
+		{[]byte{0x7d, 0x64, 0x51, 0x2a}, binary.LittleEndian, "DOS time", "2001-02-17 12:35:58"},
+		{[]byte{0x12, 0x34, 0x56, 0x78}, binary.LittleEndian, "BCD", "12345678"},
+		{[]byte{0x00, 0xe1, 0xf5, 0x05, 0x00, 0x00, 0x00, 0x00}, binary.LittleEndian, "Time64", "1973-03-03 09:46:40 UTC"},
+		{[]byte{0x00, 0x80, 0x3e, 0xd5, 0xde, 0xb1, 0x9d, 0x01}, binary.LittleEndian, "FILETIME", "1970-01-01 00:00:00 UTC"},
+		{[]byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, binary.LittleEndian, "GUID", "00112233-4455-6677-8899-aabbccddeeff"},
+		{[]byte{0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, binary.LittleEndian, "UUID", "33221100-5544-7766-8899-aabbccddeeff"},
+		{[]byte{0xe5, 0x8e, 0x26}, binary.LittleEndian, "ULEB128", "624,485 (3 bytes)"},
+		{[]byte{0xc0, 0xbb, 0x78}, binary.LittleEndian, "SLEB128", "-123,456"},
+		{[]byte{0x03}, binary.LittleEndian, "ZigZag", "-2"},
+	}
+
+	for _, test := range matrix {
+		found := false
+		for _, r := range util.Inspect(test.inp, test.order) {
+			if r.Key == test.key {
+				assert.Equal(test.expected, r.Val, "%s %x", test.key, test.inp)
+				found = true
+			}
+		}
+		assert.True(found, "%s %x", test.key, test.inp)
+	}
+
+	// Rows that are not valid for the bytes are omitted
+	for _, r := range util.Inspect([]byte{0x1a, 0x00, 0x00, 0x00}, binary.BigEndian) {
+		assert.NotEqual("BCD", r.Key)
+		assert.NotEqual("DOS time", r.Key)
+	}
+}
+
+func TestInspectBitField(t *testing.T) {
+	assert := assert.New(t)
+
+	rows := util.InspectBitField([]byte{0xb4, 0x01}, binary.LittleEndian, 2, 4)
+	if assert.Len(rows, 2) {
+		assert.Equal("Bits 2:4", rows[0].Key)
+		assert.Equal("13", rows[0].Val)
+		assert.Equal("-3", rows[1].Val)
+
+		b, err := rows[0].Encode("2")
+		assert.NoError(err)
+		assert.Equal([]byte{0x88}, b)
+		b, err = rows[1].Encode("-1")
+		assert.NoError(err)
+		assert.Equal([]byte{0xbc}, b)
+		_, err = rows[0].Encode("16")
+		assert.Error(err)
+	}
+
+	rows = util.InspectBitField([]byte{0x80, 0x01}, binary.LittleEndian, 7, 2)
+	if assert.Len(rows, 2) {
+		assert.Equal("3", rows[0].Val)
+		b, err := rows[0].Encode("0")
+		assert.NoError(err)
+		assert.Equal([]byte{0x00, 0x00}, b)
+	}
+
+	assert.Nil(util.InspectBitField([]byte{0x00}, binary.LittleEndian, 4, 8))
+}
@@ -0,0 +1,352 @@
+package util
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InspectSize is the maximum number of bytes read by Inspect.
+const InspectSize = 16
+
+// getUint reads an unsigned integer of 1 to 8 bytes in the given byte order.
+func getUint(buf []byte, size int, byteOrder binary.ByteOrder) uint64 {
+	b := make([]byte, 8)
+	if byteOrder == binary.BigEndian {
+		copy(b[8-size:], buf[:size])
+	} else {
+		copy(b, buf[:size])
+	}
+	return byteOrder.Uint64(b)
+}
+
+// signExtend sign-extends the lowest bits of v.
+func signExtend(v uint64, bits int) int64 {
+	shift := 64 - bits
+	return int64(v<<shift) >> shift
+}
+
+// float16Value converts an IEEE 754 half-precision float to a float64.
+func float16Value(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1f
+	frac := float64(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		return sign * frac * math.Pow(2, -24)
+	case 0x1f:
+		if frac != 0 {
+			return math.NaN()
+		}
+		return math.Inf(int(sign))
+	}
+	return sign * (1 + frac/1024) * math.Pow(2, float64(exp-15))
+}
+
+// float16Bits converts a float32 to an IEEE 754 half-precision float, rounding
+// to the nearest even value.
+func float16Bits(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int(b>>23&0xff) - 127 + 15
+	frac := b & 0x7fffff
+
+	switch {
+	case b&0x7fffffff > 0x7f800000:
+		// NaN
+		return sign | 0x7e00
+	case exp >= 0x1f:
+		// Overflow or infinity
+		return sign | 0x7c00
+	case exp <= 0:
+		// Subnormal or zero
+		if exp < -10 {
+			return sign
+		}
+		frac |= 0x800000
+		shift := uint(14 - exp)
+		h := uint16(frac >> shift)
+		rem, half := frac&(1<<shift-1), uint32(1)<<(shift-1)
+		if rem > half || (rem == half && h&1 == 1) {
+			h++
+		}
+		return sign | h
+	}
+
+	// A carry from rounding correctly moves into the exponent
+	h := sign | uint16(exp)<<10 | uint16(frac>>13)
+	if rem := frac & 0x1fff; rem > 0x1000 || (rem == 0x1000 && h&1 == 1) {
+		h++
+	}
+	return h
+}
+
+// bfloat16Bits converts a float32 to a bfloat16, rounding to the nearest even
+// value.
+func bfloat16Bits(f float32) uint16 {
+	b := math.Float32bits(f)
+	if b&0x7fffffff > 0x7f800000 {
+		return uint16(b>>16) | 0x40
+	}
+	b += 0x7fff + (b >> 16 & 1)
+	return uint16(b >> 16)
+}
+
+// decodeULEB128 decodes an unsigned LEB128 number and returns its value and
+// size in bytes. It returns false if buf does not start with a complete
+// number of at most 10 bytes.
+func decodeULEB128(buf []byte) (uint64, int, bool) {
+	var v uint64
+	for i := 0; i < len(buf) && i < 10; i++ {
+		v |= uint64(buf[i]&0x7f) << (7 * i)
+		if buf[i]&0x80 == 0 {
+			return v, i + 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// decodeSLEB128 decodes a signed LEB128 number and returns its value and size
+// in bytes.
+func decodeSLEB128(buf []byte) (int64, int, bool) {
+	v, size, ok := decodeULEB128(buf)
+	if !ok {
+		return 0, 0, false
+	}
+	if size*7 < 64 && buf[size-1]&0x40 != 0 {
+		v |= ^uint64(0) << (7 * size)
+	}
+	return int64(v), size, true
+}
+
+// encodeULEB128 encodes an unsigned LEB128 number, padded to size bytes so
+// that it can replace a number of the same size.
+func encodeULEB128(v uint64, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+	for {
+		out = append(out, byte(v&0x7f)|0x80)
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	return padLEB128(out, 0, size)
+}
+
+// encodeSLEB128 encodes a signed LEB128 number, padded to size bytes.
+func encodeSLEB128(v int64, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		out = append(out, b|0x80)
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			break
+		}
+	}
+	pad := byte(0)
+	if v < 0 {
+		pad = 0x7f
+	}
+	return padLEB128(out, pad, size)
+}
+
+// padLEB128 pads an encoded LEB128 number to size bytes and clears the
+// continuation bit of the last byte.
+func padLEB128(out []byte, pad byte, size int) ([]byte, error) {
+	if len(out) > size {
+		return nil, fmt.Errorf("value does not fit in %d bytes", size)
+	}
+	for len(out) < size {
+		out = append(out, pad|0x80)
+	}
+	out[len(out)-1] &^= 0x80
+	return out, nil
+}
+
+// timeLayouts are the formats accepted when typing a timestamp.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// timeFormat is the format used to display timestamps.
+const timeFormat = "2006-01-02 15:04:05"
+
+// parseTime parses a timestamp in UTC.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), " UTC")
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s (expected YYYY-MM-DD hh:mm:ss)", s)
+}
+
+// formatTime formats a timestamp in UTC, or returns false if the year cannot
+// be displayed.
+func formatTime(t time.Time) (string, bool) {
+	t = t.UTC()
+	if t.Year() < 0 || t.Year() > 9999 {
+		return "", false
+	}
+	return t.Format(timeFormat) + " UTC", true
+}
+
+// filetimeEpoch is the difference between the FILETIME epoch (1601-01-01) and
+// the Unix epoch in seconds.
+const filetimeEpoch = 11644473600
+
+// filetimeValue converts a Windows FILETIME to a time.
+func filetimeValue(v uint64) time.Time {
+	return time.Unix(int64(v/1e7)-filetimeEpoch, int64(v%1e7)*100)
+}
+
+// filetimeBits converts a time to a Windows FILETIME.
+func filetimeBits(t time.Time) (uint64, error) {
+	sec := t.Unix() + filetimeEpoch
+	if sec < 0 {
+		return 0, fmt.Errorf("time is before 1601")
+	}
+	return uint64(sec)*1e7 + uint64(t.Nanosecond()/100), nil
+}
+
+// dosTimeValue converts an MS-DOS time and date to a time, or returns false
+// if they are invalid.
+func dosTimeValue(dosTime, dosDate uint16) (time.Time, bool) {
+	year := int(dosDate>>9) + 1980
+	month := int(dosDate>>5) & 0xf
+	day := int(dosDate) & 0x1f
+	hour := int(dosTime >> 11)
+	min := int(dosTime>>5) & 0x3f
+	sec := int(dosTime&0x1f) * 2
+	if month < 1 || month > 12 || day < 1 || hour > 23 || min > 59 || sec > 59 {
+		return time.Time{}, false
+	}
+	t := time.Date(year, time.Month(month), day, hour, min, sec, 0, time.UTC)
+	if t.Day() != day {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// dosTimeBits converts a time to an MS-DOS time and date. Seconds are rounded
+// down to an even number.
+func dosTimeBits(t time.Time) (uint16, uint16, error) {
+	if t.Year() < 1980 || t.Year() > 2107 {
+		return 0, 0, fmt.Errorf("year must be between 1980 and 2107")
+	}
+	dosTime := uint16(t.Hour())<<11 | uint16(t.Minute())<<5 | uint16(t.Second()/2)
+	dosDate := uint16(t.Year()-1980)<<9 | uint16(t.Month())<<5 | uint16(t.Day())
+	return dosTime, dosDate, nil
+}
+
+// formatGUID formats 16 bytes as a GUID. If mixed is true, the first three
+// fields are little endian, as in Microsoft GUIDs.
+func formatGUID(buf []byte, mixed bool) string {
+	b := make([]byte, 16)
+	copy(b, buf[:16])
+	if mixed {
+		swapGUID(b)
+	}
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// parseGUID parses a GUID with optional braces and dashes.
+func parseGUID(s string, mixed bool) ([]byte, error) {
+	h := strings.Trim(strings.TrimSpace(s), "{}")
+	h = strings.ReplaceAll(h, "-", "")
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) != 16 {
+		return nil, fmt.Errorf("invalid GUID: %s", s)
+	}
+	if mixed {
+		swapGUID(b)
+	}
+	return b, nil
+}
+
+// swapGUID converts between the mixed endian and big endian forms of a GUID.
+func swapGUID(b []byte) {
+	b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+	b[4], b[5] = b[5], b[4]
+	b[6], b[7] = b[7], b[6]
+}
+
+// formatBCD formats packed BCD digits, or returns false if a nibble is not a
+// decimal digit.
+func formatBCD(buf []byte) (string, bool) {
+	var sb strings.Builder
+	for _, b := range buf {
+		if b>>4 > 9 || b&0xf > 9 {
+			return "", false
+		}
+		sb.WriteByte('0' + b>>4)
+		sb.WriteByte('0' + b&0xf)
+	}
+	return sb.String(), true
+}
+
+// parseBCD encodes decimal digits as size bytes of packed BCD.
+func parseBCD(s string, size int) ([]byte, error) {
+	digits := strings.TrimSpace(s)
+	if len(digits) > size*2 {
+		return nil, fmt.Errorf("value does not fit in %d bytes", size)
+	}
+	digits = strings.Repeat("0", size*2-len(digits)) + digits
+	out := make([]byte, size)
+	for i := range digits {
+		if digits[i] < '0' || digits[i] > '9' {
+			return nil, fmt.Errorf("invalid BCD number: %s", s)
+		}
+		out[i/2] |= (digits[i] - '0') << (4 * (1 - i%2))
+	}
+	return out, nil
+}
+
+// InspectBitField returns rows showing a field of width bits at the given bit
+// offset, counted from the least significant bit of the integer at the start
+// of buf. The integer is the smallest of 1, 2, 4 or 8 bytes that holds the
+// field, read in the given byte order.
+func InspectBitField(buf []byte, byteOrder binary.ByteOrder, offset, width int) []Row {
+	size := 1
+	for size*8 < offset+width {
+		size *= 2
+	}
+	if width < 1 || size > 8 || len(buf) < size {
+		return nil
+	}
+
+	v := getUint(buf, size, byteOrder)
+	mask := ^uint64(0) >> (64 - width)
+	field := v >> offset & mask
+	key := fmt.Sprintf("Bits %d:%d", offset, width)
+
+	encode := func(signed bool) func(string) ([]byte, error) {
+		return func(s string) ([]byte, error) {
+			n, err := parseNumber(s, signed, width)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number for a %d-bit field: %s", width, s)
+			}
+			return putUint(v&^(mask<<offset)|(n&mask)<<offset, size, byteOrder), nil
+		}
+	}
+
+	return []Row{
+		{key, strconv.FormatUint(field, 10), encode(false)},
+		{"", strconv.FormatInt(signExtend(field, width), 10), encode(true)},
+	}
+}
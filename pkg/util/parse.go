@@ -0,0 +1,131 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseNumber parses an unsigned number. The base can be given with a 0x, 0o,
+// 0b or 0d prefix, or an h suffix for hex. Numbers without a prefix or suffix
+// are parsed in the default base. Underscores can be used as digit
+// separators.
+func ParseNumber(s string, defaultBase int) (uint64, error) {
+	str := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "_", "")
+	base := defaultBase
+
+	switch {
+	case strings.HasPrefix(str, "0x"):
+		str, base = str[2:], 16
+	case strings.HasPrefix(str, "0o"):
+		str, base = str[2:], 8
+	case strings.HasPrefix(str, "0b") && defaultBase != 16:
+		str, base = str[2:], 2
+	case strings.HasPrefix(str, "0d") && defaultBase != 16:
+		str, base = str[2:], 10
+	case strings.HasSuffix(str, "h"):
+		str, base = str[:len(str)-1], 16
+	}
+
+	n, err := strconv.ParseUint(str, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", s)
+	}
+	return n, nil
+}
+
+// ParseSize parses a size such as "4096", "0x1000" or "4K". The K, M and G
+// suffixes, optionally followed by "iB" or "B", multiply by powers of 1024. A
+// decimal number may also end in "B". Since "b" is a hex digit, it is never
+// taken as a suffix of a hex number such as "0x1b".
+func ParseSize(s string) (uint64, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	if unit := strings.TrimSuffix(str, "b"); unit != str {
+		unit = strings.TrimSuffix(unit, "i")
+		switch {
+		case strings.HasSuffix(unit, "k"), strings.HasSuffix(unit, "m"), strings.HasSuffix(unit, "g"):
+			str = unit
+		case !strings.HasSuffix(str, "ib") && isDecimal(unit):
+			str = unit
+		}
+	}
+
+	shift := 0
+	switch {
+	case strings.HasSuffix(str, "k"):
+		shift = 10
+	case strings.HasSuffix(str, "m"):
+		shift = 20
+	case strings.HasSuffix(str, "g"):
+		shift = 30
+	}
+	if shift > 0 {
+		str = str[:len(str)-1]
+	}
+
+	n, err := ParseNumber(str, 10)
+	if err != nil || n > (1<<63-1)>>shift {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	return n << shift, nil
+}
+
+// isDecimal returns whether the string is a decimal number, allowing
+// underscores as digit separators.
+func isDecimal(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParsePattern parses a byte pattern. A pattern in double quotes is text with
+// Go escapes, such as "AB\x00". Otherwise, it is hex with an optional 0x
+// prefix, where spaces are ignored.
+func ParsePattern(s string) ([]byte, error) {
+	str := strings.TrimSpace(s)
+	if strings.HasPrefix(str, "\"") {
+		text, err := strconv.Unquote(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text pattern: %s", s)
+		}
+		if text == "" {
+			return nil, fmt.Errorf("pattern is empty")
+		}
+		return []byte(text), nil
+	}
+
+	str = strings.Join(strings.Fields(str), "")
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "0x"), "0X")
+	if str == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	if len(str)%2 != 0 {
+		str = "0" + str
+	}
+	b, parsed := HexStringToBytes(str)
+	for _, ok := range parsed {
+		if !ok {
+			return nil, fmt.Errorf("invalid hex pattern: %s", s)
+		}
+	}
+	return b, nil
+}
+
+// RepeatPattern repeats the pattern to fill n bytes. The last repetition is
+// cut short if needed.
+func RepeatPattern(pattern []byte, n int) []byte {
+	out := make([]byte, n)
+	if len(pattern) == 0 {
+		return out
+	}
+	for i := 0; i < n; {
+		i += copy(out[i:], pattern)
+	}
+	return out
+}
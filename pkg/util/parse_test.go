@@ -0,0 +1,110 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNumber(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		base     int
+		expected uint64
+		expError bool
+	}{
+		{"ff", 16, 0xff, false},
+		{"0x10", 10, 0x10, false},
+		{"10", 10, 10, false},
+		{"10h", 10, 0x10, false},
+		{"0b101", 10, 5, false},
+		{"0b101", 16, 0x0b101, false},
+		{"0o17", 16, 15, false},
+		{"0d99", 10, 99, false},
+		{"0800_0000", 16, 0x08000000, false},
+		{"zz", 16, 0, true},
+		{"", 10, 0, true},
+	}
+
+	for _, test := range matrix {
+		n, err := util.ParseNumber(test.inp, test.base)
+		if test.expError {
+			assert.Error(err, test.inp)
+		} else {
+			assert.NoError(err, test.inp)
+			assert.Equal(test.expected, n, test.inp)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected uint64
+		expError bool
+	}{
+		{"4096", 4096, false},
+		{"0x1000", 0x1000, false},
+		{"4K", 4096, false},
+		{"4KiB", 4096, false},
+		{"2m", 2 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"16B", 16, false},
+		{"16iB", 0, true},
+		{"0x10K", 0x10 << 10, false},
+		{"0x1b", 0x1b, false},
+		{"0xab", 0xab, false},
+		{"0xb", 0xb, false},
+		{"1bh", 0x1b, false},
+		{"K", 0, true},
+		{"4X", 0, true},
+	}
+
+	for _, test := range matrix {
+		n, err := util.ParseSize(test.inp)
+		if test.expError {
+			assert.Error(err, test.inp)
+		} else {
+			assert.NoError(err, test.inp)
+			assert.Equal(test.expected, n, test.inp)
+		}
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	assert := assert.New(t)
+
+	var matrix = []struct {
+		inp      string
+		expected []byte
+		expError bool
+	}{
+		{"ff", []byte{0xff}, false},
+		{"0xdead beef", []byte{0xde, 0xad, 0xbe, 0xef}, false},
+		{"abc", []byte{0x0a, 0xbc}, false},
+		{`"AB\x00"`, []byte{'A', 'B', 0}, false},
+		{`"a b"`, []byte("a b"), false},
+		{"zz", nil, true},
+		{`"open`, nil, true},
+		{`""`, nil, true},
+		{"", nil, true},
+	}
+
+	for _, test := range matrix {
+		b, err := util.ParsePattern(test.inp)
+		if test.expError {
+			assert.Error(err, test.inp)
+		} else {
+			assert.NoError(err, test.inp)
+			assert.Equal(test.expected, b, test.inp)
+		}
+	}
+
+	assert.Equal([]byte{1, 2, 3, 1, 2, 3, 1}, util.RepeatPattern([]byte{1, 2, 3}, 7))
+	assert.Equal([]byte{0, 0}, util.RepeatPattern(nil, 2))
+}
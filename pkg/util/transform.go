@@ -0,0 +1,133 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The transform functions return a transformed copy of the data and leave the
+// data itself untouched. Word transforms treat the data as a sequence of
+// unsigned integers of 1, 2, 4 or 8 bytes, and wrap around on overflow.
+
+// applyKey combines each byte of data with the key, which is repeated as
+// needed.
+func applyKey(data, key []byte, op func(a, b byte) byte) []byte {
+	out := make([]byte, len(data))
+	if len(key) == 0 {
+		copy(out, data)
+		return out
+	}
+	for i, b := range data {
+		out[i] = op(b, key[i%len(key)])
+	}
+	return out
+}
+
+// XOR returns the data XORed with a repeating key.
+func XOR(data, key []byte) []byte {
+	return applyKey(data, key, func(a, b byte) byte { return a ^ b })
+}
+
+// AND returns the data ANDed with a repeating key.
+func AND(data, key []byte) []byte {
+	return applyKey(data, key, func(a, b byte) byte { return a & b })
+}
+
+// OR returns the data ORed with a repeating key.
+func OR(data, key []byte) []byte {
+	return applyKey(data, key, func(a, b byte) byte { return a | b })
+}
+
+// NOT returns the data with every bit inverted.
+func NOT(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = ^b
+	}
+	return out
+}
+
+// checkWordSize returns an error unless size is a supported word size and n
+// is a multiple of it.
+func checkWordSize(n, size int) error {
+	switch size {
+	case 1, 2, 4, 8:
+	default:
+		return fmt.Errorf("word size must be 1, 2, 4 or 8")
+	}
+	if n%size != 0 {
+		return fmt.Errorf("length %d is not a multiple of the word size %d", n, size)
+	}
+	return nil
+}
+
+// mapWords replaces each word of the data with the result of fn, truncated to
+// the word size.
+func mapWords(data []byte, size int, byteOrder binary.ByteOrder, fn func(v uint64) uint64) ([]byte, error) {
+	if err := checkWordSize(len(data), size); err != nil {
+		return nil, err
+	}
+
+	mask := ^uint64(0) >> (64 - 8*size)
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += size {
+		v := getUint(data[i:], size, byteOrder)
+		copy(out[i:], putUint(fn(v)&mask, size, byteOrder))
+	}
+	return out, nil
+}
+
+// AddWords adds delta to every word of the data.
+func AddWords(data []byte, delta uint64, size int, byteOrder binary.ByteOrder) ([]byte, error) {
+	return mapWords(data, size, byteOrder, func(v uint64) uint64 { return v + delta })
+}
+
+// SubWords subtracts delta from every word of the data.
+func SubWords(data []byte, delta uint64, size int, byteOrder binary.ByteOrder) ([]byte, error) {
+	return mapWords(data, size, byteOrder, func(v uint64) uint64 { return v - delta })
+}
+
+// RotateWords rotates the bits of every word of the data left by n bits, or
+// right if n is negative.
+func RotateWords(data []byte, n int, size int, byteOrder binary.ByteOrder) ([]byte, error) {
+	bits := 8 * size
+	return mapWords(data, size, byteOrder, func(v uint64) uint64 {
+		k := ((n % bits) + bits) % bits
+		return v<<k | v>>(bits-k)
+	})
+}
+
+// ShiftWords shifts the bits of every word of the data left by n bits, or
+// right if n is negative. Bits shifted in are zero.
+func ShiftWords(data []byte, n int, size int, byteOrder binary.ByteOrder) ([]byte, error) {
+	return mapWords(data, size, byteOrder, func(v uint64) uint64 {
+		if n < 0 {
+			return v >> -n
+		}
+		return v << n
+	})
+}
+
+// SwapBytes reverses the order of the bytes within every word of the data.
+func SwapBytes(data []byte, size int) ([]byte, error) {
+	if err := checkWordSize(len(data), size); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += size {
+		for j := 0; j < size; j++ {
+			out[i+j] = data[i+size-1-j]
+		}
+	}
+	return out, nil
+}
+
+// Reverse returns the data in reverse order.
+func Reverse(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[len(data)-1-i] = b
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package util_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyTransforms(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []byte{0x00, 0x0f, 0xf0, 0xff, 0x55}
+	key := []byte{0xaa, 0x0f}
+
+	assert.Equal([]byte{0xaa, 0x00, 0x5a, 0xf0, 0xff}, util.XOR(data, key))
+	assert.Equal([]byte{0x00, 0x0f, 0xa0, 0x0f, 0x00}, util.AND(data, key))
+	assert.Equal([]byte{0xaa, 0x0f, 0xfa, 0xff, 0xff}, util.OR(data, key))
+	assert.Equal([]byte{0xff, 0xf0, 0x0f, 0x00, 0xaa}, util.NOT(data))
+	assert.Equal(data, util.XOR(util.XOR(data, key), key))
+	assert.Equal([]byte{0x00, 0x0f, 0xf0, 0xff, 0x55}, data, "input is not modified")
+}
+
+func TestWordTransforms(t *testing.T) {
+	assert := assert.New(t)
+
+	le, be := binary.LittleEndian, binary.BigEndian
+	var matrix = []struct {
+		name     string
+		fn       func([]byte) ([]byte, error)
+		inp      []byte
+		expected []byte
+		expError bool
+	}{
+		{"add 1", func(b []byte) ([]byte, error) { return util.AddWords(b, 1, 1, le) }, []byte{0x00, 0xff}, []byte{0x01, 0x00}, false},
+		{"add le16", func(b []byte) ([]byte, error) { return util.AddWords(b, 1, 2, le) }, []byte{0xff, 0x00, 0xff, 0xff}, []byte{0x00, 0x01, 0x00, 0x00}, false},
+		{"add be16", func(b []byte) ([]byte, error) { return util.AddWords(b, 0x101, 2, be) }, []byte{0x00, 0xff}, []byte{0x02, 0x00}, false},
+		{"add 64", func(b []byte) ([]byte, error) { return util.AddWords(b, 2, 8, be) }, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, []byte{0, 0, 0, 0, 0, 0, 0, 1}, false},
+		{"sub 1", func(b []byte) ([]byte, error) { return util.SubWords(b, 1, 1, le) }, []byte{0x00, 0x10}, []byte{0xff, 0x0f}, false},
+		{"sub le32", func(b []byte) ([]byte, error) { return util.SubWords(b, 1, 4, le) }, []byte{0x00, 0x00, 0x01, 0x00}, []byte{0xff, 0xff, 0x00, 0x00}, false},
+		{"rol 1", func(b []byte) ([]byte, error) { return util.RotateWords(b, 1, 1, le) }, []byte{0x81, 0x40}, []byte{0x03, 0x80}, false},
+		{"ror 1", func(b []byte) ([]byte, error) { return util.RotateWords(b, -1, 1, le) }, []byte{0x81, 0x40}, []byte{0xc0, 0x20}, false},
+		{"rol 9", func(b []byte) ([]byte, error) { return util.RotateWords(b, 9, 1, le) }, []byte{0x81}, []byte{0x03}, false},
+		{"rol 8", func(b []byte) ([]byte, error) { return util.RotateWords(b, 8, 1, le) }, []byte{0x81}, []byte{0x81}, false},
+		{"rol be16", func(b []byte) ([]byte, error) { return util.RotateWords(b, 4, 2, be) }, []byte{0x12, 0x34}, []byte{0x23, 0x41}, false},
+		{"rol le16", func(b []byte) ([]byte, error) { return util.RotateWords(b, 4, 2, le) }, []byte{0x34, 0x12}, []byte{0x41, 0x23}, false},
+		{"shl 1", func(b []byte) ([]byte, error) { return util.ShiftWords(b, 1, 1, le) }, []byte{0x81}, []byte{0x02}, false},
+		{"shr 1", func(b []byte) ([]byte, error) { return util.ShiftWords(b, -1, 1, le) }, []byte{0x81}, []byte{0x40}, false},
+		{"shl be16", func(b []byte) ([]byte, error) { return util.ShiftWords(b, 4, 2, be) }, []byte{0x12, 0x34}, []byte{0x23, 0x40}, false},
+		{"shl 64", func(b []byte) ([]byte, error) { return util.ShiftWords(b, 64, 1, le) }, []byte{0xff}, []byte{0x00}, false},
+		{"bswap 2", func(b []byte) ([]byte, error) { return util.SwapBytes(b, 2) }, []byte{1, 2, 3, 4}, []byte{2, 1, 4, 3}, false},
+		{"bswap 4", func(b []byte) ([]byte, error) { return util.SwapBytes(b, 4) }, []byte{1, 2, 3, 4}, []byte{4, 3, 2, 1}, false},
+		{"bad size", func(b []byte) ([]byte, error) { return util.AddWords(b, 1, 3, le) }, []byte{1, 2, 3}, nil, true},
+		{"bad rotate size", func(b []byte) ([]byte, error) { return util.RotateWords(b, 1, 0, le) }, []byte{1}, nil, true},
+		{"unaligned", func(b []byte) ([]byte, error) { return util.SwapBytes(b, 2) }, []byte{1, 2, 3}, nil, true},
+	}
+
+	for _, test := range matrix {
+		out, err := test.fn(test.inp)
+		if test.expError {
+			assert.Error(err, test.name)
+		} else {
+			assert.NoError(err, test.name)
+			assert.Equal(test.expected, out, test.name)
+		}
+	}
+}
+
+func TestReverse(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]byte{3, 2, 1}, util.Reverse([]byte{1, 2, 3}))
+	assert.Equal([]byte{}, util.Reverse([]byte{}))
+}
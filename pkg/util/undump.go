@@ -0,0 +1,234 @@
+package util
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DumpChunk is a run of bytes read from a line of a hex dump.
+type DumpChunk struct {
+	Offset int64
+	Data   []byte
+}
+
+// dumpLine is a line of a hex dump, split into its offset and the rest.
+type dumpLine struct {
+	lineNo int
+	offset int64
+	rest   string
+	repeat bool
+
+	// cut is true if the text column was delimited by a '|'
+	cut bool
+}
+
+// ParseDump parses a hex dump in the format of gex dump, xxd or hexdump -C.
+// Each line starts with an offset in hex, optionally followed by a colon,
+// then the bytes in hex, optionally grouped, and an optional text column.
+//
+// The number of bytes per line is detected from the offsets, or is cols if
+// the dump has a single line. Hex continuing a full line is an error. If the
+// text column is delimited by a '|' at both ends, as in hexdump -C, a line
+// can hold any number of bytes. A line of hexdump's "*" repeats the previous
+// line up to the next offset. Lines with only an offset produce an empty
+// chunk, which marks the end of the data.
+func ParseDump(r io.Reader, cols int) ([]DumpChunk, error) {
+	return parseDump(r, cols, false)
+}
+
+// ParseEditableDump parses a hex dump in the layout of hexdump -C that has
+// been edited by hand. The text column is removed whatever its length, as
+// bytes may have been added or removed without updating it, and the line can
+// hold any number of bytes.
+func ParseEditableDump(r io.Reader, cols int) ([]DumpChunk, error) {
+	return parseDump(r, cols, true)
+}
+
+func parseDump(r io.Reader, cols int, edited bool) ([]DumpChunk, error) {
+	lines, err := readDumpLines(r, edited)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detect the number of bytes per line from consecutive data lines
+	detected := int64(0)
+	for i := 1; i < len(lines); i++ {
+		prev, cur := lines[i-1], lines[i]
+		if prev.repeat || cur.repeat || strings.TrimSpace(cur.rest) == "" {
+			continue
+		}
+		if d := cur.offset - prev.offset; d > 0 && (detected == 0 || d < detected) {
+			detected = d
+		}
+	}
+	if detected > 0 {
+		cols = int(detected)
+	}
+
+	chunks := make([]DumpChunk, 0, len(lines))
+	for i, line := range lines {
+		if !line.repeat {
+			limit := cols
+			if line.cut {
+				limit = math.MaxInt
+			}
+			data, err := parseDumpBytes(line.rest, limit)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.lineNo, err)
+			}
+			chunks = append(chunks, DumpChunk{Offset: line.offset, Data: data})
+			continue
+		}
+
+		// Repeat the previous line up to the offset of the next line
+		if len(chunks) == 0 || i+1 >= len(lines) || lines[i+1].repeat {
+			return nil, fmt.Errorf("line %d: nothing to repeat", line.lineNo)
+		}
+		prev := chunks[len(chunks)-1]
+		if len(prev.Data) == 0 {
+			continue
+		}
+		next := lines[i+1].offset
+		for off := prev.Offset + int64(len(prev.Data)); off+int64(len(prev.Data)) <= next; off += int64(len(prev.Data)) {
+			chunks = append(chunks, DumpChunk{Offset: off, Data: prev.Data})
+		}
+	}
+
+	return chunks, nil
+}
+
+// readDumpLines reads the lines of a hex dump and splits off their offsets.
+// Text columns delimited by a '|' are cut off whatever their length if edited
+// is set.
+func readDumpLines(r io.Reader, edited bool) ([]dumpLine, error) {
+	lines := make([]dumpLine, 0)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if trimmed == "*" {
+			lines = append(lines, dumpLine{lineNo: lineNo, repeat: true})
+			continue
+		}
+
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		end := strings.IndexFunc(line, unicode.IsSpace)
+		if end < 0 {
+			end = len(line)
+		}
+		tok := strings.TrimSuffix(line[:end], ":")
+		offset, err := strconv.ParseInt(tok, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid offset %q", lineNo, tok)
+		}
+		rest, cut := cutTextColumn(line[end:], edited)
+		lines = append(lines, dumpLine{lineNo: lineNo, offset: offset, rest: rest, cut: cut})
+	}
+
+	return lines, sc.Err()
+}
+
+// cutTextColumn removes the text column of hexdump -C from the part of a line
+// after the offset. The column is delimited by a '|' at both ends and has a
+// character for each byte, which tells it apart from a text column that
+// happens to contain a '|', as the text itself may. Unless the length has to
+// match, the column starts at the first '|', as the hex has none.
+func cutTextColumn(s string, anyLength bool) (string, bool) {
+	s = strings.TrimRight(s, " \t")
+	if !strings.HasSuffix(s, "|") {
+		return s, false
+	}
+	if anyLength {
+		if i := strings.IndexByte(s, '|'); i < len(s)-1 && (i == 0 || s[i-1] == ' ') {
+			return s[:i], true
+		}
+		return s, false
+	}
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] != '|' || s[i-1] != ' ' {
+			continue
+		}
+		data, err := parseDumpBytes(s[:i], math.MaxInt)
+		if err == nil && len(data) > 0 && len(data) == len(s)-i-2 {
+			return s[:i], true
+		}
+	}
+	return s, false
+}
+
+// parseDumpBytes parses up to limit bytes of hex from the part of a line after
+// the offset. The hex ends at the limit, at a token that is not hex, or at a
+// gap of three or more spaces, which separates a partial line from its text
+// column. Hex separated from the limit by a single space continues the bytes
+// rather than starting the text column, and is an error.
+func parseDumpBytes(s string, limit int) ([]byte, error) {
+	out := make([]byte, 0)
+	for len(out) < limit {
+		gap := len(s) - len(strings.TrimLeft(s, " "))
+		s = s[gap:]
+		if s == "" || s[0] == '\t' || (len(out) > 0 && gap >= 3) {
+			break
+		}
+
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		b, err := hex.DecodeString(s[:end])
+		if err != nil {
+			break
+		}
+		if len(out)+len(b) > limit {
+			return nil, fmt.Errorf("line is longer than %d bytes", limit)
+		}
+		out = append(out, b...)
+		s = s[end:]
+	}
+
+	if len(out) == limit && len(s) > 1 && s[0] == ' ' && !unicode.IsSpace(rune(s[1])) {
+		if _, err := hex.DecodeString(strings.Fields(s)[0]); err == nil {
+			return nil, fmt.Errorf("line is longer than %d bytes", limit)
+		}
+	}
+	return out, nil
+}
+
+// ParsePlainHex parses a plain hex dump such as the output of xxd -p. All
+// whitespace is ignored.
+func ParsePlainHex(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := strings.Join(strings.Fields(string(b)), "")
+	out, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+	return out, nil
+}
+
+// AssembleDump joins the chunks of a hex dump into a single slice that starts
+// at offset 0. Gaps between chunks are filled with zeros.
+func AssembleDump(chunks []DumpChunk) []byte {
+	size := int64(0)
+	for _, c := range chunks {
+		size = Max(size, c.Offset+int64(len(c.Data)))
+	}
+	out := make([]byte, size)
+	for _, c := range chunks {
+		copy(out[c.Offset:], c.Data)
+	}
+	return out
+}
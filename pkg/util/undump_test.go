@@ -0,0 +1,149 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hizkifw/gex/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDump(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []byte("Hello, world!\x00\x01\x02\xff\nsecond line of text here")
+
+	var matrix = []struct {
+		name string
+		inp  string
+		cols int
+	}{
+		{"gex", "" +
+			"00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 00 01 02  Hello, world!...\n" +
+			"00000010  ff 0a 73 65 63 6f 6e 64  20 6c 69 6e 65 20 6f 66  ..second line of\n" +
+			"00000020  20 74 65 78 74 20 68 65  72 65                     text here\n", 16},
+		{"xxd", "" +
+			"00000000: 4865 6c6c 6f2c 2077 6f72 6c64 2100 0102  Hello, world!...\n" +
+			"00000010: ff0a 7365 636f 6e64 206c 696e 6520 6f66  ..second line of\n" +
+			"00000020: 2074 6578 7420 6865 7265                 text here\n", 16},
+		{"hexdump", "" +
+			"00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 00 01 02  |Hello, world!...|\n" +
+			"00000010  ff 0a 73 65 63 6f 6e 64  20 6c 69 6e 65 20 6f 66  |..second line of|\n" +
+			"00000020  20 74 65 78 74 20 68 65  72 65                    | text here|\n" +
+			"0000002a\n", 16},
+		{"cols", "" +
+			"00000000  48 65 6c 6c 6f 2c 20 77  Hello, w\n" +
+			"00000008  6f 72 6c 64 21 00 01 02  orld!...\n" +
+			"00000010  ff 0a 73 65 63 6f 6e 64  ..second\n" +
+			"00000018  20 6c 69 6e 65 20 6f 66   line of\n" +
+			"00000020  20 74 65 78 74 20 68 65   text he\n" +
+			"00000028  72 65                    re\n", 16},
+		{"single line", "0: 48656c6c6f2c20776f726c6421000102ff0a7365636f6e64206c696e65206f6620746578742068657265  text", 42},
+	}
+
+	for _, test := range matrix {
+		chunks, err := util.ParseDump(strings.NewReader(test.inp), test.cols)
+		if assert.NoError(err, test.name) {
+			assert.Equal(data, util.AssembleDump(chunks), test.name)
+		}
+	}
+}
+
+func TestParseDump_Repeat(t *testing.T) {
+	assert := assert.New(t)
+
+	inp := "" +
+		"00000000  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|\n" +
+		"*\n" +
+		"00000030  41 42                                             |AB|\n" +
+		"00000032\n"
+	chunks, err := util.ParseDump(strings.NewReader(inp), 16)
+	assert.NoError(err)
+	assert.Equal(append(make([]byte, 0x30), 'A', 'B'), util.AssembleDump(chunks))
+
+	// Hex-looking text after a full line is not part of the data
+	chunks, err = util.ParseDump(strings.NewReader("00000000  ca fe  cafe\n00000002  be ef  beef\n"), 16)
+	assert.NoError(err)
+	assert.Equal([]byte{0xca, 0xfe, 0xbe, 0xef}, util.AssembleDump(chunks))
+
+	// Offsets are kept so that dumps can patch files
+	chunks, err = util.ParseDump(strings.NewReader("00000100: 4142\n"), 16)
+	assert.NoError(err)
+	assert.Equal([]util.DumpChunk{{Offset: 0x100, Data: []byte("AB")}}, chunks)
+
+	// Lines with a delimited text column can hold any number of bytes
+	chunks, err = util.ParseDump(strings.NewReader("00000000  41 42 43  |ABC|\n00000002  44 |D|\n"), 2)
+	assert.NoError(err)
+	assert.Equal([]byte("ABCD"), append(chunks[0].Data, chunks[1].Data...))
+	_, err = util.ParseDump(strings.NewReader("00000000  414243\n00000002  45\n"), 16)
+	assert.Error(err)
+
+	// A '|' in the data is not taken as the start of a text column
+	chunks, err = util.ParseDump(strings.NewReader(""+
+		"00000000  61 62 7c 63 64 65 66 67  68 69 6a 6b 6c 6d 6e 6f  ab|cdefghijklmno\n"+
+		"00000010  41 42 43 44 45 46 47 48  49 4a 4b 4c 4d 4e 4f 50  ABCDEFGHIJKLMNOP\n"+
+		"00000020  51                                                Q\n"), 16)
+	assert.NoError(err)
+	assert.Equal([]byte("ab|cdefghijklmnoABCDEFGHIJKLMNOPQ"), util.AssembleDump(chunks))
+	chunks, err = util.ParseDump(strings.NewReader(""+
+		"00000000: 6162 7c63 6465 6667 6869 6a6b 6c6d 6e6f  ab|cdefghijklmno\n"+
+		"00000010: 7c41 4243 4445 4647 4849 4a4b 4c4d 4e7c  |ABCDEFGHIJKLMN|\n"), 16)
+	assert.NoError(err)
+	assert.Equal([]byte("ab|cdefghijklmno|ABCDEFGHIJKLMN|"), util.AssembleDump(chunks))
+	chunks, err = util.ParseDump(strings.NewReader(""+
+		"00000000  61 62 7c 20 7c 63  |ab| |c|\n"+
+		"00000006\n"), 16)
+	assert.NoError(err)
+	assert.Equal([]byte("ab| |c"), util.AssembleDump(chunks))
+
+	// Hex added to a full line is not mistaken for its text column
+	_, err = util.ParseDump(strings.NewReader(""+
+		"00000000  41 42 43 44 45 46 47 48  49 4a 4b 4c 4d 4e 4f 50 ff  ABCDEFGHIJKLMNOP\n"+
+		"00000010  51                                                Q\n"), 16)
+	assert.Error(err)
+	_, err = util.ParseDump(strings.NewReader(""+
+		"00000000  41 42 43 44 45 46 47 48  49 4a 4b 4c 4d 4e 4f 50 ff |ABCDEFGHIJKLMNOP|\n"+
+		"00000010  51                                               |Q|\n"), 16)
+	assert.Error(err)
+
+	_, err = util.ParseDump(strings.NewReader("zz 41 42\n"), 16)
+	assert.Error(err)
+	_, err = util.ParseDump(strings.NewReader("*\n00000010 41\n"), 16)
+	assert.Error(err)
+}
+
+func TestParsePlainHex(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := util.ParsePlainHex(strings.NewReader("48656c\n6c6f 0a\n"))
+	assert.NoError(err)
+	assert.Equal([]byte("Hello\n"), b)
+
+	_, err = util.ParsePlainHex(strings.NewReader("486"))
+	assert.Error(err)
+}
+
+func TestParseEditableDump(t *testing.T) {
+	assert := assert.New(t)
+
+	// Bytes inserted in the middle of lines and removed from them, without
+	// updating the text column
+	inp := "" +
+		"00000000  41 42 43 44 45 46 47 48  ff fe 49 4a 4b 4c 4d 4e 4f 50 |ABCDEFGHIJKLMNOP|\n" +
+		"00000010  51 7c 20 7c                                         |Q| ||\n" +
+		"00000014  53 54 |RSTU|\n"
+	chunks, err := util.ParseEditableDump(strings.NewReader(inp), 16)
+	if assert.NoError(err) {
+		data := make([]byte, 0)
+		for _, c := range chunks {
+			data = append(data, c.Data...)
+		}
+		assert.Equal([]byte("ABCDEFGH\xff\xfeIJKLMNOPQ| |ST"), data)
+	}
+
+	// Without the text column, a line still cannot grow past the columns
+	_, err = util.ParseEditableDump(strings.NewReader(""+
+		"00000000  41 42 43 44 45 46 47 48  49 4a 4b 4c 4d 4e 4f 50 ff\n"+
+		"00000010  51 |Q|\n"), 16)
+	assert.Error(err)
+}